@@ -4,12 +4,12 @@ go 1.23.3
 
 require (
 	github.com/stretchr/testify v1.9.0
-     // Other dependencies
+	gopkg.in/yaml.v3 v3.0.1
+// Other dependencies
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.5.2 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,204 @@
+// Package cli implements the roundrobinator command line: serving the APIs, and checking and printing
+// configurations so they can be verified before a rollout.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+	"github.com/samargupta114/Roundrobinator.git/internal/server"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK    = 0 // The command succeeded
+	ExitError = 1 // The command failed, e.g. on an invalid configuration
+	ExitUsage = 2 // The command line is invalid
+)
+
+// launch starts the servers; tests replace it to check what would be served.
+var launch = server.Launch
+
+// command is a subcommand of the command line.
+type command struct {
+	name    string
+	args    string // Synopsis of the arguments
+	summary string
+	run     func(r *runner, args []string) int
+}
+
+// commands lists every subcommand, in the order the usage shows them.
+var commands = []command{
+	{name: "serve", args: "[flags]", summary: "launch the Round Robin API and the Application API servers (default)", run: (*runner).serve},
+	{name: "validate", args: "[file] [flags]", summary: "check a configuration and report every problem", run: (*runner).validate},
+	{name: "print-config", args: "[-format json|yaml] [flags]", summary: "print the fully resolved effective configuration", run: (*runner).printConfig},
+	{name: "schema", summary: "print the JSON Schema of the configuration format", run: (*runner).schema},
+	{name: "version", summary: "print the version and build information", run: (*runner).version},
+}
+
+// runner holds the environment a command runs in.
+type runner struct {
+	environ []string // "KEY=value" pairs as returned by os.Environ
+	stdout  io.Writer
+	stderr  io.Writer
+
+	synopsis   string // Arguments of the running command, shown by its usage
+	usageShown bool   // Whether a flag set reported an invalid command line, or help, on stderr
+}
+
+// Run runs the command line args, without the program name, and returns the exit code.
+// Without a subcommand, or when args start with a flag, the servers are launched as with serve.
+func Run(args, environ []string, stdout, stderr io.Writer) int {
+	r := &runner{environ: environ, stdout: stdout, stderr: stderr}
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "-help" && args[0] != "--help" {
+		args = append([]string{"serve"}, args...)
+	}
+
+	name := args[0]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			r.synopsis = cmd.args
+			return cmd.run(r, args[1:])
+		}
+	}
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		r.usage(stdout)
+		return ExitOK
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+	r.usage(stderr)
+	return ExitUsage
+}
+
+// usage lists the commands.
+func (r *runner) usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: roundrobinator <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run \"roundrobinator <command> -h\" for the flags of a command.")
+}
+
+// flagSet returns the flag set of a command, reporting parse errors on stderr.
+func (r *runner) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("roundrobinator "+name, flag.ContinueOnError)
+	fs.SetOutput(r.stderr)
+	fs.Usage = func() {
+		r.usageShown = true
+		fmt.Fprintf(r.stderr, "Usage: roundrobinator %s %s\n\nFlags:\n", name, r.synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// fail reports an error of a command and returns its exit code.
+// Help requests succeed, and flag errors were already reported by the flag set.
+func (r *runner) fail(err error) int {
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case r.usageShown:
+		return ExitUsage
+	}
+	fmt.Fprintln(r.stderr, err)
+	return ExitError
+}
+
+// load resolves and validates the configuration from the defaults, the config file, the environment and args.
+func (r *runner) load(name string, args []string) (*config.Resolved, error) {
+	resolved, err := config.ResolveFlags(r.flagSet(name), args, r.environ)
+	if err != nil {
+		return nil, err
+	}
+	if err := resolved.Config.Validate(); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// serve launches the servers, reloading the configuration from the same sources while they run.
+func (r *runner) serve(args []string) int {
+	// Refuse to launch anything on a configuration the servers cannot run with
+	resolved, err := r.load("serve", args)
+	if err != nil {
+		//push alerts
+		return r.fail(fmt.Errorf("Failed to load config: %w", err))
+	}
+
+	if resolved.DebugConfig {
+		log.Printf("Effective configuration:")
+		if err := resolved.Dump(log.Writer()); err != nil {
+			log.Printf("Failed to dump config: %v", err)
+		}
+	}
+
+	launch(resolved.Config, server.Reload{
+		File: resolved.File,
+		Load: func() (*config.Config, error) {
+			reloaded, err := r.load("serve", args)
+			if err != nil {
+				return nil, err
+			}
+			return reloaded.Config, nil
+		},
+	})
+	return ExitOK
+}
+
+// validate checks a configuration as serve would, and reports every problem.
+// The file can be given as the first argument instead of through -config or ROUND_ROBIN_CONF_PATH.
+func (r *runner) validate(args []string) int {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		args = append([]string{"-config", args[0]}, args[1:]...)
+	}
+
+	resolved, err := r.load("validate", args)
+	if err != nil {
+		return r.fail(err)
+	}
+	fmt.Fprintf(r.stdout, "%s: configuration is valid\n", resolved.File)
+	return ExitOK
+}
+
+// printConfig prints the effective configuration, with every layer applied, as JSON or YAML.
+// Secret values are redacted.
+func (r *runner) printConfig(args []string) int {
+	fs := r.flagSet("print-config")
+	format := fs.String("format", config.FormatJSON, "output format: json or yaml")
+
+	resolved, err := config.ResolveFlags(fs, args, r.environ)
+	if err != nil {
+		return r.fail(err)
+	}
+	redacted, err := resolved.Config.Redact()
+	if err != nil {
+		return r.fail(err)
+	}
+	data, err := config.Encode(redacted, *format)
+	if err != nil {
+		return r.fail(err)
+	}
+	if _, err := r.stdout.Write(data); err != nil {
+		return r.fail(err)
+	}
+	return ExitOK
+}
+
+// schema prints the JSON Schema of the configuration, for editors and linters to check config files with.
+func (r *runner) schema(args []string) int {
+	if err := r.flagSet("schema").Parse(args); err != nil {
+		return r.fail(err)
+	}
+	if _, err := r.stdout.Write(config.Schema()); err != nil {
+		return r.fail(err)
+	}
+	return ExitOK
+}
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+	"github.com/samargupta114/Roundrobinator.git/internal/server"
+)
+
+// writeConfig writes content to a config file named name in a temporary directory and returns its path.
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// run runs the command line and returns its exit code, stdout and stderr.
+func run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(args, nil, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+const validConfig = `
+server:
+  port: 9090
+backend:
+  routes: [8081, 8082]
+`
+
+func TestValidate(t *testing.T) {
+	valid := writeConfig(t, "valid.yaml", validConfig)
+	invalid := writeConfig(t, "invalid.yaml", `
+server:
+  port: 70000
+  timeout: -1s
+backend:
+  routes: [8081]
+`)
+
+	code, stdout, stderr := run("validate", valid)
+	assert.Equal(t, ExitOK, code)
+	assert.Equal(t, valid+": configuration is valid\n", stdout)
+	assert.Empty(t, stderr)
+
+	// Flags apply on top of the file
+	code, _, stderr = run("validate", valid, "-server.port=0")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "server.port")
+
+	// Every problem is reported at once
+	code, stdout, stderr = run("validate", invalid)
+	assert.Equal(t, ExitError, code)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "server.port")
+	assert.Contains(t, stderr, "server.timeout")
+
+	code, _, stderr = run("validate", filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Equal(t, ExitError, code)
+	assert.NotEmpty(t, stderr)
+
+	code, _, stderr = run("validate", valid, "-unknown")
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, stderr, "Usage: roundrobinator validate [file] [flags]")
+}
+
+func TestPrintConfig(t *testing.T) {
+	path := writeConfig(t, "app-config.yaml", validConfig)
+
+	code, stdout, stderr := run("print-config", "-config", path, "-server.timeout=45s")
+	assert.Equal(t, ExitOK, code, stderr)
+	cfg, err := config.Decode([]byte(stdout), config.FormatJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, "9090", cfg.Server.Port)
+	assert.Equal(t, config.Seconds(45), cfg.Server.Timeout)
+	assert.Equal(t, config.Routes("8081", "8082"), cfg.Backend.Routes)
+
+	code, stdout, stderr = run("print-config", "-format", "yaml", "-config", path)
+	assert.Equal(t, ExitOK, code, stderr)
+	assert.Contains(t, stdout, "port: \"9090\"\n")
+	yamlCfg, err := config.Decode([]byte(stdout), config.FormatYAML)
+	assert.NoError(t, err)
+	assert.Equal(t, cfg.Backend, yamlCfg.Backend)
+
+	// Secrets are redacted
+	code, stdout, stderr = run("print-config", "-config", path,
+		"-set", "health_check.probes.8081.type=exec", "-set", "health_check.probes.8081.env.TOKEN=s3cr3t")
+	assert.Equal(t, ExitOK, code, stderr)
+	assert.NotContains(t, stdout, "s3cr3t")
+	assert.Contains(t, stdout, `"TOKEN": "`+config.Redacted+`"`)
+
+	code, _, stderr = run("print-config", "-format", "toml", "-config", path)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, `unsupported config format "toml"`)
+}
+
+func TestSchema(t *testing.T) {
+	code, stdout, _ := run("schema")
+	assert.Equal(t, ExitOK, code)
+	assert.Equal(t, string(config.Schema()), stdout)
+	assert.True(t, json.Valid([]byte(stdout)))
+}
+
+func TestVersion(t *testing.T) {
+	defer func(read func() (*debug.BuildInfo, bool)) { readBuildInfo = read }(readBuildInfo)
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			GoVersion: "go1.27.1",
+			Main:      debug.Module{Path: "github.com/samargupta114/Roundrobinator.git", Version: "v1.4.0"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "b5ddf45"},
+				{Key: "vcs.time", Value: "2026-10-01T12:00:00Z"},
+				{Key: "vcs.modified", Value: "true"},
+			},
+		}, true
+	}
+
+	code, stdout, _ := run("version")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stdout, "roundrobinator v1.4.0\n")
+	assert.Contains(t, stdout, "go:       go1.27.1\n")
+	assert.Contains(t, stdout, "revision: b5ddf45 (modified)\n")
+	assert.Contains(t, stdout, "time:     2026-10-01T12:00:00Z\n")
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return nil, false }
+	code, _, stderr := run("version")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "build information is not available")
+}
+
+func TestRun_Serve(t *testing.T) {
+	defer func(l func(*config.Config, server.Reload)) { launch = l }(launch)
+	var launched *config.Config
+	var reload server.Reload
+	launch = func(cfg *config.Config, r server.Reload) { launched, reload = cfg, r }
+
+	path := writeConfig(t, "app-config.yaml", validConfig)
+
+	// Flags without a command serve, as before commands existed
+	for _, args := range [][]string{{"-config", path}, {"serve", "-config", path}} {
+		launched = nil
+		code, _, stderr := run(args...)
+		assert.Equal(t, ExitOK, code, stderr)
+		if assert.NotNil(t, launched, args) {
+			assert.Equal(t, "9090", launched.Server.Port)
+			assert.Equal(t, path, reload.File)
+		}
+	}
+
+	reloaded, err := reload.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "9090", reloaded.Server.Port)
+
+	// Nothing is launched on an invalid configuration
+	launched = nil
+	code, _, stderr := run("serve", "-config", path, "-server.port=0")
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr, "Failed to load config")
+	assert.Nil(t, launched)
+}
+
+func TestRun_Usage(t *testing.T) {
+	code, stdout, _ := run("help")
+	assert.Equal(t, ExitOK, code)
+	for _, cmd := range commands {
+		assert.Contains(t, stdout, cmd.name)
+	}
+
+	code, _, stderr := run("deploy")
+	assert.Equal(t, ExitUsage, code)
+	assert.Contains(t, stderr, `unknown command "deploy"`)
+
+	code, _, stderr = run("serve", "-h")
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stderr, "Usage: roundrobinator serve [flags]")
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// readBuildInfo returns the build information embedded in the binary; tests replace it.
+var readBuildInfo = debug.ReadBuildInfo
+
+// version prints the version of the binary, with the build information recorded by the Go toolchain.
+func (r *runner) version(args []string) int {
+	fs := r.flagSet("version")
+	if err := fs.Parse(args); err != nil {
+		return r.fail(err)
+	}
+
+	info, ok := readBuildInfo()
+	if !ok {
+		return r.fail(errors.New("build information is not available in this binary"))
+	}
+
+	settings := make(map[string]string, len(info.Settings))
+	for _, setting := range info.Settings {
+		settings[setting.Key] = setting.Value
+	}
+
+	fmt.Fprintf(r.stdout, "roundrobinator %s\n", info.Main.Version)
+	fmt.Fprintf(r.stdout, "  module:   %s\n", info.Main.Path)
+	fmt.Fprintf(r.stdout, "  go:       %s\n", info.GoVersion)
+	if revision := settings["vcs.revision"]; revision != "" {
+		if settings["vcs.modified"] == "true" {
+			revision += " (modified)"
+		}
+		fmt.Fprintf(r.stdout, "  revision: %s\n", revision)
+	}
+	if built := settings["vcs.time"]; built != "" {
+		fmt.Fprintf(r.stdout, "  time:     %s\n", built)
+	}
+	fmt.Fprintf(r.stdout, "  platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	return ExitOK
+}
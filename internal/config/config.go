@@ -1,13 +1,17 @@
 package config
 
 import (
-	"encoding/json"
-	"fmt"
 	"os"
 )
 
 const (
 	confPath = "ROUND_ROBIN_CONF_PATH" // Environment variable that specifies the config file path
+
+	// HealthcheckEndpoint is the key of the health check entry in Backend.Endpoint.
+	HealthcheckEndpoint = "healthcheck"
+
+	// MaxJitterPercent is the upper bound for HealthCheck.JitterPercent.
+	MaxJitterPercent = 50
 )
 
 // Config holds the overall configuration for the application, including server settings, backend configurations, and health check , graceful shutdown intervals.
@@ -15,28 +19,145 @@ type Config struct {
 	Server  Server  `json:"server"`  // Server configuration settings
 	Backend Backend `json:"backend"` // Backend configuration for API routing and endpoints
 
-	// HealthCheckTickerTimeInSeconds defines the interval for health check ticks in seconds.
+	// Pools are named sets of backends the routing table forwards requests to, keyed by pool name.
+	// backend.routes forms the "default" pool.
+	Pools map[string]Pool `json:"pools"`
+
+	// Routing is the routing table that picks the pool of every request.
+	Routing Routing `json:"routing"`
+
+	// HealthCheckInterval defines the interval for health check ticks.
 	// It specifies how often the system should check the health of the backend services.
-	HealthCheckTickerTimeInSeconds int64 `json:"healthCheck_ticker_time_seconds"`
+	HealthCheckInterval Duration `json:"healthCheck_ticker_time_seconds"`
+
+	// GracefulTimeout specifies the time allowed for graceful shutdown of the server.
+	GracefulTimeout Duration `json:"graceful_timeout_seconds"`
+
+	// HealthCheck holds the tuning knobs for the background health checker.
+	HealthCheck HealthCheck `json:"health_check"`
+
+	// Reload controls how the configuration is reloaded while the servers run.
+	Reload Reload `json:"reload"`
+
+	// IncludeDir is a directory of fragment files merged into the configuration, relative to the config file.
+	// Fragments are JSON or YAML files with the same schema, merged in file name order; pools and routing rules
+	// are keyed by name, and a value defined by two files is an error. It can only be set in the main config file.
+	IncludeDir string `json:"include_dir"`
+}
+
+// Reload represents the configuration for reloading the configuration without a restart.
+// A SIGHUP always reloads it.
+type Reload struct {
+	// WatchInterval is how often the config file is checked for changes, which are then reloaded.
+	// A value of 0 disables watching the file.
+	WatchInterval Duration `json:"watch_interval_seconds"`
+}
+
+// HealthCheck represents the configuration for the background health checker.
+type HealthCheck struct {
+	// Workers bounds how many health probes may run concurrently.
+	// A value of 0 falls back to the checker's built-in default.
+	Workers int `json:"workers"`
+
+	// UnhealthyInterval is the probe interval for targets whose last probe failed,
+	// so they are noticed as soon as they recover. A value of 0 falls back to the checker's built-in default.
+	UnhealthyInterval Duration `json:"unhealthy_interval_seconds"`
+
+	// JitterPercent randomizes every probe interval by up to +/- this percentage so targets are not probed in bursts.
+	JitterPercent int `json:"jitter_percent"`
+
+	// MinHealthyBackends is the number of healthy backends the Round Robin API needs to report ready on /readyz.
+	// Values below 1 are treated as 1.
+	MinHealthyBackends int `json:"min_healthy_backends"`
 
-	// GracefulTimeoutSeconds specifies the time allowed for graceful shutdown of the server.
-	GracefulTimeoutSeconds int64 `json:"graceful_timeout_seconds"`
+	// PanicThresholdPercent is the percentage of healthy backends below which the balancer ignores health
+	// and spreads traffic across every backend. A value of 0 disables panic mode.
+	PanicThresholdPercent int `json:"panic_threshold_percent"`
+
+	// HistorySize is the number of health transitions kept in memory per backend.
+	// A value of 0 falls back to the checker's built-in default.
+	HistorySize int `json:"history_size"`
+
+	// HistoryFile is an optional JSONL file every health transition is appended to.
+	// Transitions already in the file are loaded back into memory at startup.
+	HistoryFile string `json:"history_file"`
+
+	// Probes overrides the health probe of individual backends, keyed by backend route name (its port or URL).
+	// Backends without an entry are probed over HTTP on the healthcheck endpoint.
+	Probes map[string]Probe `json:"probes"`
+}
+
+// Probe defines how a single backend is health checked.
+type Probe struct {
+	// Type selects the kind of probe: "http" (default) or "exec".
+	Type string `json:"type"`
+
+	// Command is the executable an "exec" probe runs; exit code 0 means healthy.
+	Command string `json:"command"`
+
+	// Args are the arguments passed to Command.
+	Args []string `json:"args"`
+
+	// Env holds extra environment variables for Command, on top of the process environment.
+	// Values are secret: they can be given as env:NAME or file:/path references, and are redacted when printed.
+	Env map[string]string `json:"env" secret:"true"`
+
+	// Timeout specifies the timeout for a single probe.
+	// A value of 0 falls back to the healthcheck endpoint timeout.
+	Timeout Duration `json:"timeout"`
 }
 
 // Server represents the configuration for the server settings.
 type Server struct {
-	Port    string `json:"port"`    // The port on which the server should listen
-	Timeout int    `json:"timeout"` // The timeout in seconds for server requests
+	Port    string   `json:"port"`    // The port on which the server should listen
+	Timeout Duration `json:"timeout"` // The timeout for server requests
+
+	// Policy holds the server-wide defaults of the timeouts, retries and body size of forwarded requests,
+	// which pools and routing rules override.
+	Policy Policy `json:"policy"`
+
+	// TrustedProxies lists the addresses or CIDR networks of the proxies in front of the Round Robin API.
+	// The X-Forwarded-* and Forwarded headers of requests from them are kept and appended to; those of
+	// any other client are replaced. Empty trusts no one.
+	TrustedProxies []string `json:"trusted_proxies"`
 }
 
 // Backend holds the configuration for backend services, including server routes and endpoints.
 type Backend struct {
-	// Routes is a list of routes (e.g., ports) where the backend services are available.
-	Routes []string `json:"routes"`
+	// Routes is a list of routes where the backend services are available:
+	// bare ports served on localhost, full URLs, or objects with a URL and backend metadata.
+	Routes []Route `json:"routes"`
 
 	// Endpoint is a map of endpoint configurations, where the key is the endpoint name (e.g., "health_check")
 	// and the value holds the specific URL and timeout for that endpoint.
 	Endpoint map[string]Endpoint `json:"endpoints"`
+
+	// Checks lists the dependency checks reported by the /health endpoint of the Application API servers.
+	Checks []DependencyCheck `json:"checks"`
+}
+
+// DependencyCheck defines a named check of something the Application API depends on.
+type DependencyCheck struct {
+	// Name identifies the check in health responses.
+	Name string `json:"name"`
+
+	// Type selects the kind of check: "disk" or "http".
+	Type string `json:"type"`
+
+	// Path is the filesystem path whose free space a "disk" check measures.
+	Path string `json:"path"`
+
+	// MinFreeBytes is the free space below which a "disk" check fails.
+	MinFreeBytes uint64 `json:"min_free_bytes"`
+
+	// URL is the address an "http" check expects a non-error response from.
+	URL string `json:"url"`
+
+	// Timeout specifies the timeout for a single run of the check.
+	Timeout Duration `json:"timeout"`
+
+	// Critical marks checks whose failure makes the server unhealthy rather than degraded.
+	Critical bool `json:"critical"`
 }
 
 // Endpoint defines the configuration for a single backend endpoint.
@@ -45,32 +166,25 @@ type Endpoint struct {
 	URL string `json:"url"`
 
 	// Timeout specifies the timeout for requests to this endpoint.
-	Timeout int `json:"timeout"`
+	Timeout Duration `json:"timeout"`
 }
 
-// LoadConfig reads the configuration file specified by the environment variable
-// ROUND_ROBIN_CONF_PATH, unmarshal its content, and returns the populated Config struct.
+// LoadConfig resolves the configuration from the built-in defaults, the file specified by the environment variable
+// ROUND_ROBIN_CONF_PATH and the RR_* environment variables, and returns the populated Config struct.
 func LoadConfig() (*Config, error) {
-	// Get the config file path from environment variable
-	configPath := os.Getenv(confPath)
-	if configPath == "" {
-		// push alerts
-		return nil, fmt.Errorf("config path not set in environment variable : %s", confPath)
-	}
-
-	// Open the config file
-	file, err := os.Open(configPath)
+	r, err := Resolve(nil, os.Environ())
 	if err != nil {
-		// push alerts
-		return nil, fmt.Errorf("failed to load config: %v", err)
+		return nil, err
 	}
-	defer file.Close()
+	return r.Config, nil
+}
 
-	// Unmarshal JSON content into Config struct
+// LoadFile reads a JSON or YAML configuration file and returns the populated Config struct, without defaults.
+// The format is detected from the file extension, or from the content when the extension is not recognized.
+func LoadFile(path string) (*Config, error) {
 	var cfg Config
-	if err := json.NewDecoder(file).Decode(&cfg); err != nil {
-		// push alerts
-		return nil, fmt.Errorf("failed to decode config: %v", err)
+	if _, err := loadFileInto(&cfg, path); err != nil {
+		return nil, err
 	}
 
 	// Return the populated Config struct
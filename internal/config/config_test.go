@@ -39,19 +39,19 @@ func TestLoadConfig(t *testing.T) {
 			expectedConfig: &Config{
 				Server: Server{
 					Port:    "8080",
-					Timeout: 30,
+					Timeout: Seconds(30),
 				},
 				Backend: Backend{
-					Routes: []string{"8081", "8082"},
+					Routes: Routes("8081", "8082"),
 					Endpoint: map[string]Endpoint{
 						"health_check": {
 							URL:     "http://localhost:8080/health",
-							Timeout: 10,
+							Timeout: Seconds(10),
 						},
 					},
 				},
-				HealthCheckTickerTimeInSeconds: 30,
-				GracefulTimeoutSeconds:         15,
+				HealthCheckInterval: Seconds(30),
+				GracefulTimeout:     Seconds(15),
 			},
 		},
 		{
@@ -124,8 +124,8 @@ func TestLoadConfig(t *testing.T) {
 				// Validate fields of expectedConfig
 				assert.Equal(t, tt.expectedConfig.Server.Port, cfg.Server.Port)
 				assert.Equal(t, tt.expectedConfig.Server.Timeout, cfg.Server.Timeout)
-				assert.Equal(t, tt.expectedConfig.HealthCheckTickerTimeInSeconds, cfg.HealthCheckTickerTimeInSeconds)
-				assert.Equal(t, tt.expectedConfig.GracefulTimeoutSeconds, cfg.GracefulTimeoutSeconds)
+				assert.Equal(t, tt.expectedConfig.HealthCheckInterval, cfg.HealthCheckInterval)
+				assert.Equal(t, tt.expectedConfig.GracefulTimeout, cfg.GracefulTimeout)
 				assert.Len(t, cfg.Backend.Routes, len(tt.expectedConfig.Backend.Routes))
 				for i, route := range tt.expectedConfig.Backend.Routes {
 					assert.Equal(t, route, cfg.Backend.Routes[i])
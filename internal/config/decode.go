@@ -0,0 +1,328 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported configuration file formats.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// DetectFormat returns the format of a configuration file from its extension,
+// falling back to its content: JSON documents start with '{'.
+func DetectFormat(path string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// Decode parses a JSON or YAML configuration document into a Config.
+// Both formats share the schema defined by the JSON field names, and errors report the line and column at fault.
+func Decode(data []byte, format string) (*Config, error) {
+	var cfg Config
+	if err := decodeInto(&cfg, data, format); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// decodeInto parses a JSON or YAML document into v.
+func decodeInto(v interface{}, data []byte, format string) error {
+	switch format {
+	case FormatJSON:
+		return decodeJSON(v, data)
+	case FormatYAML:
+		return decodeYAML(v, data)
+	default:
+		return fmt.Errorf("unsupported config format %q", format)
+	}
+}
+
+// decodeJSON parses a JSON document into v, translating error offsets into line and column.
+// Fields that are not part of the schema are rejected.
+func decodeJSON(v interface{}, data []byte) error {
+	err := unmarshalStrict(data, v)
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, column := position(data, syntaxErr.Offset)
+		return fmt.Errorf("line %d, column %d: %v", line, column, err)
+	case errors.As(err, &typeErr) && typeErr.Offset > 0:
+		line, column := position(data, typeErr.Offset)
+		return fmt.Errorf("line %d, column %d: %v", line, column, err)
+	case err != nil:
+		// Errors of types with their own decoding carry no offset, so look for the value that fails
+		if _, offset, ok := locate(json.NewDecoder(bytes.NewReader(data)), reflect.TypeOf(v), ""); ok {
+			line, column := position(data, offset)
+			return fmt.Errorf("line %d, column %d: %v", line, column, err)
+		}
+	}
+	return err
+}
+
+// locate reads the next value from dec, decoded as type t, and looks for the first value within it
+// of a type with its own JSON decoding that fails to decode. It returns the field path of that value
+// and the offset just past it, or false when every such value decodes.
+func locate(dec *json.Decoder, t reflect.Type, path string) (string, int64, bool) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t != nil && reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return "", 0, false
+		}
+		if err := json.Unmarshal(raw, reflect.New(t).Interface()); err != nil {
+			return path, dec.InputOffset(), true
+		}
+		return "", 0, false
+	}
+
+	token, err := dec.Token()
+	if err != nil {
+		return "", 0, false
+	}
+	switch token {
+	case json.Delim('{'):
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return "", 0, false
+			}
+			name, _ := key.(string)
+			if path, offset, ok := locate(dec, fieldType(t, name), joinPath(path, name)); ok {
+				return path, offset, true
+			}
+		}
+	case json.Delim('['):
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for i := 0; dec.More(); i++ {
+			if path, offset, ok := locate(dec, elem, joinPath(path, strconv.Itoa(i))); ok {
+				return path, offset, true
+			}
+		}
+	default:
+		return "", 0, false // A scalar
+	}
+	_, _ = dec.Token() // The closing delimiter
+	return "", 0, false
+}
+
+// unmarshalStrict works like json.Unmarshal but rejects unknown fields and trailing data.
+func unmarshalStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after the end of the document")
+	}
+	return nil
+}
+
+// position returns the 1-based line and column of the last byte read before offset,
+// which is where encoding/json stopped when it reported the error.
+func position(data []byte, offset int64) (line, column int) {
+	offset = min(max(offset-1, 0), int64(len(data)))
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// decodeYAML parses a YAML document into v by converting it to the equivalent JSON document,
+// so both formats share one schema. Decoding errors are mapped back to the YAML line and column.
+func decodeYAML(v interface{}, data []byte) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err // yaml errors already carry the line number
+	}
+	if root.Kind == 0 {
+		return errors.New("empty YAML document")
+	}
+
+	conv := &yamlConverter{nodes: make(map[string]*yaml.Node)}
+	value, err := conv.convert(&root, reflect.TypeOf(v), "")
+	if err != nil {
+		return err
+	}
+
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	err = unmarshalStrict(jsonData, v)
+	if err == nil {
+		return nil
+	}
+	path, ok := "", false
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		path, ok = typeErr.Field, true
+	} else {
+		// Errors of types with their own decoding carry no field path, so look for the value that fails
+		path, _, ok = locate(json.NewDecoder(bytes.NewReader(jsonData)), reflect.TypeOf(v), "")
+	}
+	if node := conv.lookup(path); ok && node != nil {
+		return fmt.Errorf("line %d, column %d: %v", node.Line, node.Column, err)
+	}
+	return err
+}
+
+// yamlConverter turns a YAML node tree into plain values encodable as JSON,
+// remembering the node behind every field path for error reporting.
+type yamlConverter struct {
+	nodes map[string]*yaml.Node // Keyed by dotted field path, as reported by encoding/json
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// convert converts node, guided by the Go type t it will be decoded into (nil when unknown).
+// Scalars decoded into string fields stay strings, so unquoted YAML values such as `port: 8080` are accepted.
+func (c *yamlConverter) convert(node *yaml.Node, t reflect.Type, path string) (interface{}, error) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.AliasNode {
+		return c.convert(node.Alias, t, path)
+	}
+	// Types with their own JSON decoding get the values as written, except for the fields of their object form
+	if t != nil && reflect.PointerTo(t).Implements(jsonUnmarshalerType) &&
+		!(node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct) {
+		t = nil
+	}
+	if path != "" {
+		c.nodes[path] = node
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return c.convert(node.Content[0], t, path)
+
+	case yaml.MappingNode:
+		result := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			if keyNode.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d, column %d: mapping keys must be scalars", keyNode.Line, keyNode.Column)
+			}
+			key := keyNode.Value
+
+			value, err := c.convert(valueNode, fieldType(t, key), joinPath(path, key))
+			if err != nil {
+				return nil, err
+			}
+			result[key] = value
+		}
+		return result, nil
+
+	case yaml.SequenceNode:
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		result := make([]interface{}, len(node.Content))
+		for i, item := range node.Content {
+			value, err := c.convert(item, elem, joinPath(path, strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = value
+		}
+		return result, nil
+
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return nil, nil
+		}
+		if t != nil && t.Kind() == reflect.String {
+			return node.Value, nil
+		}
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, fmt.Errorf("line %d, column %d: %v", node.Line, node.Column, err)
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("line %d, column %d: unsupported YAML node", node.Line, node.Column)
+}
+
+// lookup returns the node at a field path, or at its closest parent when the exact path is unknown.
+func (c *yamlConverter) lookup(path string) *yaml.Node {
+	for path != "" {
+		if node, ok := c.nodes[path]; ok {
+			return node
+		}
+		i := strings.LastIndexByte(path, '.')
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return nil
+}
+
+// fieldType returns the type of the value stored under key in a value of type t, or nil when unknown.
+func fieldType(t reflect.Type, key string) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem()
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if jsonName(field) == key {
+				return field.Type
+			}
+		}
+	}
+	return nil
+}
+
+// jsonName returns the JSON name of a struct field.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// joinPath appends a segment to a dotted field path.
+func joinPath(path, segment string) string {
+	if path == "" {
+		return segment
+	}
+	return path + "." + segment
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const jsonConfig = `{
+  "server": {
+    "port": "8080",
+    "timeout": 10
+  },
+  "backend": {
+    "routes": [
+      "8081",
+      "https://mirror.internal:8443/v1",
+      {"url": "http://10.0.0.7:9000", "weight": 3, "zone": "eu-west-1b", "labels": {"tier": "gold"}}
+    ],
+    "endpoints": {
+      "healthcheck": {"url": "/health", "timeout": 2}
+    },
+    "checks": [
+      {"name": "disk", "type": "disk", "path": "/", "min_free_bytes": 104857600, "timeout": 2, "critical": true}
+    ]
+  },
+  "healthCheck_ticker_time_seconds": 45,
+  "health_check": {
+    "workers": 4,
+    "jitter_percent": 10,
+    "probes": {
+      "8081": {"type": "exec", "command": "/usr/local/bin/ping", "args": ["--port", "8081"], "env": {"MODE": "quick"}}
+    }
+  },
+  "graceful_timeout_seconds": 10
+}`
+
+const yamlConfig = `server:
+  port: 8080 # unquoted, still decoded into the string field
+  timeout: 10
+backend:
+  routes:
+    - 8081
+    - https://mirror.internal:8443/v1
+    - url: http://10.0.0.7:9000
+      weight: 3
+      zone: eu-west-1b
+      labels:
+        tier: gold
+  endpoints:
+    healthcheck:
+      url: /health
+      timeout: 2
+  checks:
+    - name: disk
+      type: disk
+      path: /
+      min_free_bytes: 104857600
+      timeout: 2
+      critical: true
+healthCheck_ticker_time_seconds: 45
+health_check:
+  workers: 4
+  jitter_percent: 10
+  probes:
+    "8081":
+      type: exec
+      command: /usr/local/bin/ping
+      args: ["--port", "8081"]
+      env:
+        MODE: quick
+graceful_timeout_seconds: 10
+`
+
+// TestDecode_JSONAndYAMLAreEquivalent checks that the same configuration in both formats decodes to an identical Config.
+func TestDecode_JSONAndYAMLAreEquivalent(t *testing.T) {
+	fromJSON, err := Decode([]byte(jsonConfig), FormatJSON)
+	assert.NoError(t, err)
+
+	fromYAML, err := Decode([]byte(yamlConfig), FormatYAML)
+	assert.NoError(t, err)
+
+	assert.Equal(t, fromJSON, fromYAML)
+	assert.Equal(t, "8080", fromYAML.Server.Port)
+	assert.Equal(t, "8081", fromYAML.Backend.Routes[0].URL)
+	assert.Equal(t, 3, fromYAML.Backend.Routes[2].Weight)
+}
+
+func TestDecode_ErrorsReportPosition(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        string
+		content       string
+		expectedError string
+	}{
+		{
+			name:          "JSON syntax error",
+			format:        FormatJSON,
+			content:       "{\n  \"server\": {\n    \"port\": \"8080\",\n  }\n}",
+			expectedError: "line 4, column 3: invalid character '}'",
+		},
+		{
+			name:          "JSON type error",
+			format:        FormatJSON,
+			content:       "{\n  \"server\": {\n    \"timeout\": \"ten\"\n  }\n}",
+			expectedError: "line 3, column 20: json: cannot unmarshal string",
+		},
+		{
+			name:          "JSON type error in a list",
+			format:        FormatJSON,
+			content:       "{\n  \"server\": {\"port\": \"8080\"},\n  \"backend\": {\"routes\": [8081, {\"url\": 8082, \"weight\": \"x\"}]}\n}",
+			expectedError: "line 3, column 59: route must be a port, a URL or an object with a url",
+		},
+		{
+			name:          "YAML syntax error",
+			format:        FormatYAML,
+			content:       "server:\n  port: 8080\n timeout: 10\n",
+			expectedError: "yaml: line 2: did not find expected key",
+		},
+		{
+			name:          "YAML type error",
+			format:        FormatYAML,
+			content:       "server:\n  port: 8080\n  timeout: ten\n",
+			expectedError: "line 3, column 12: json: cannot unmarshal string \"ten\" into Go value of type config.Duration",
+		},
+		{
+			name:          "YAML type error in a list",
+			format:        FormatYAML,
+			content:       "backend:\n  checks:\n    - name: disk\n      timeout: soon\n",
+			expectedError: "line 4, column 16: json: cannot unmarshal string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Decode([]byte(tt.content), tt.format)
+			assert.Nil(t, cfg)
+			assert.ErrorContains(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		expected string
+	}{
+		{name: "JSON extension", path: "app-config.json", content: "", expected: FormatJSON},
+		{name: "YAML extension", path: "app-config.yaml", content: "{}", expected: FormatYAML},
+		{name: "YML extension", path: "app-config.YML", content: "", expected: FormatYAML},
+		{name: "JSON content", path: "app-config", content: "  \n{\"server\": {}}", expected: FormatJSON},
+		{name: "YAML content", path: "app-config.conf", content: "server:\n  port: 8080\n", expected: FormatYAML},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectFormat(tt.path, []byte(tt.content)))
+		})
+	}
+}
+
+func TestLoadFile_YAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app-config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(yamlConfig), 0o644))
+
+	cfg, err := LoadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "8080", cfg.Server.Port)
+
+	// Errors name the file
+	assert.NoError(t, os.WriteFile(path, []byte("server:\n  timeout: ten\n"), 0o644))
+	_, err = LoadFile(path)
+	assert.ErrorContains(t, err, path+": line 2, column 12")
+}
+
+func TestDecode_UnknownFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        string
+		content       string
+		expectedError string
+	}{
+		{name: "JSON", format: FormatJSON, content: `{"server": {"prot": "8080"}}`, expectedError: `unknown field "prot"`},
+		{name: "YAML", format: FormatYAML, content: "healthcheck_ticker: 30\n", expectedError: `unknown field "healthcheck_ticker"`},
+		{name: "Route object", format: FormatYAML, content: "backend:\n  routes:\n    - url: http://10.0.0.7\n      wieght: 2\n", expectedError: `unknown field "wieght"`},
+		{name: "Trailing data", format: FormatJSON, content: `{} {}`, expectedError: "unexpected data after the end of the document"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode([]byte(tt.content), tt.format)
+			assert.ErrorContains(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestDecode_YAMLRouteObjectWithPort(t *testing.T) {
+	cfg, err := Decode([]byte("backend:\n  routes:\n    - url: 8081\n      weight: 2\n"), FormatYAML)
+	assert.NoError(t, err)
+	assert.Equal(t, []Route{{URL: "8081", Weight: 2}}, cfg.Backend.Routes)
+}
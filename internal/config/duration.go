@@ -0,0 +1,57 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// Duration is a length of time in the configuration. It is written as a Go duration string, e.g. "1.5s" or "250ms",
+// or as a number of seconds, as the settings were before they accepted strings.
+type Duration time.Duration
+
+// Seconds returns the Duration of n seconds.
+func Seconds(n int64) Duration {
+	return Duration(time.Duration(n) * time.Second)
+}
+
+// Duration returns d as a time.Duration.
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+// String formats d as a Go duration string.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// UnmarshalJSON accepts a Go duration string or a number of seconds.
+// Numeric strings are accepted as seconds too, as environment variables and flags always give strings.
+// Other values are reported as a *json.UnmarshalTypeError, so the decoder adds the field path.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return &json.UnmarshalTypeError{Value: string(data), Type: reflect.TypeOf(*d)}
+	}
+	if err := json.Unmarshal([]byte(text), &seconds); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return &json.UnmarshalTypeError{Value: "string " + string(data), Type: reflect.TypeOf(*d)}
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON writes d as a Go duration string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuration_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    time.Duration
+		expectedErr string
+	}{
+		{name: "Legacy seconds", input: `30`, expected: 30 * time.Second},
+		{name: "Fractional seconds", input: `0.25`, expected: 250 * time.Millisecond},
+		{name: "Duration string", input: `"1.5s"`, expected: 1500 * time.Millisecond},
+		{name: "Milliseconds", input: `"250ms"`, expected: 250 * time.Millisecond},
+		{name: "Compound", input: `"1m30s"`, expected: 90 * time.Second},
+		{name: "Numeric string", input: `"45"`, expected: 45 * time.Second},
+		{name: "Negative", input: `"-2s"`, expected: -2 * time.Second},
+		{name: "Missing unit", input: `"1.5"`, expected: 1500 * time.Millisecond},
+		{name: "Invalid string", input: `"soon"`, expectedErr: `json: cannot unmarshal string "soon" into Go value of type config.Duration`},
+		{name: "Invalid type", input: `true`, expectedErr: `json: cannot unmarshal true into Go value of type config.Duration`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Duration
+			err := json.Unmarshal([]byte(tt.input), &d)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, d.Duration())
+		})
+	}
+}
+
+func TestDuration_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Timeout Duration `json:"timeout"`
+	}{Timeout: Duration(1500 * time.Millisecond)})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"timeout":"1.5s"}`, string(data))
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Encode writes a configuration as a JSON or YAML document that Decode reads back into the same Config.
+// Fields are written in declaration order, durations as Go duration strings.
+func Encode(cfg *Config, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatJSON:
+		return append(data, '\n'), nil
+	case FormatYAML:
+		// JSON is valid YAML, so parsing it keeps the field order; only the flow style and quotes are dropped
+		var root yaml.Node
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, err
+		}
+		blockStyle(&root)
+
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&root); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", format)
+	}
+}
+
+// blockStyle resets the style of a node tree parsed from JSON, so it is written as block YAML
+// with strings quoted only where they would otherwise read as another type.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode_RoundTrip(t *testing.T) {
+	cfg := validConfig()
+	cfg.Backend.Routes = append(cfg.Backend.Routes, Route{URL: "http://10.0.0.7:9000", Weight: 3, Labels: map[string]string{"tier": "gold"}})
+	cfg.Pools = map[string]Pool{"api": {Backends: Routes("https://api-2.internal"), Policy: Policy{Timeout: Duration(1500 * time.Millisecond)}}}
+
+	for _, format := range []string{FormatJSON, FormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			data, err := Encode(cfg, format)
+			assert.NoError(t, err)
+
+			decoded, err := Decode(data, format)
+			assert.NoError(t, err)
+			assert.Equal(t, cfg, decoded)
+		})
+	}
+}
+
+func TestEncode_YAML(t *testing.T) {
+	cfg := &Config{Server: Server{Port: "8080", Timeout: Seconds(10)}, Backend: Backend{Routes: Routes("8081")}}
+
+	data, err := Encode(cfg, FormatYAML)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "server:\n  port: \"8080\"\n  timeout: 10s\n")
+	assert.Contains(t, string(data), "  routes:\n    - \"8081\"\n")
+}
+
+func TestEncode_UnsupportedFormat(t *testing.T) {
+	_, err := Encode(&Config{}, "toml")
+	assert.EqualError(t, err, `unsupported config format "toml"`)
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FragmentFiles returns the fragment files of the include_dir of a configuration read from file, in merge order:
+// the JSON and YAML files of the directory sorted by name. Hidden files and subdirectories are skipped.
+func (c *Config) FragmentFiles(file string) ([]string, error) {
+	if c.IncludeDir == "" {
+		return nil, nil
+	}
+	dir := c.IncludeDir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(file), dir)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read include_dir: %v", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".json", ".yaml", ".yml":
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(files) // ReadDir sorts by name already; keep the order explicit
+	return files, nil
+}
+
+// fragmentMerger merges the documents of a config file and its fragments, remembering the file behind every value.
+type fragmentMerger struct {
+	owners map[string]string // File that defined every merged path
+	rules  map[string]string // File that defined every named routing rule
+}
+
+// mergeFragments merges the fragment files of the configuration in doc, read from file, into doc.
+func mergeFragments(file string, doc map[string]interface{}, cfg *Config) error {
+	files, err := cfg.FragmentFiles(file)
+	if err != nil {
+		return err
+	}
+
+	m := &fragmentMerger{owners: make(map[string]string), rules: make(map[string]string)}
+	m.record(doc, file, "")
+	for _, fragment := range files {
+		data, err := os.ReadFile(fragment)
+		if err != nil {
+			return fmt.Errorf("failed to load config fragment: %v", err)
+		}
+
+		// Decode every fragment on its own first, so errors report its line and column
+		format := DetectFormat(fragment, data)
+		var fragmentCfg Config
+		if err := decodeInto(&fragmentCfg, data, format); err != nil {
+			return fmt.Errorf("failed to decode config fragment %s: %v", fragment, err)
+		}
+		if fragmentCfg.IncludeDir != "" {
+			return fmt.Errorf("config fragment %s: include_dir can only be set in the main config file", fragment)
+		}
+
+		fragmentDoc, err := decodeDocument(data, format)
+		if err != nil {
+			return fmt.Errorf("failed to decode config fragment %s: %v", fragment, err)
+		}
+		values, _ := fragmentDoc.(map[string]interface{})
+		if err := m.merge(doc, values, fragment, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record remembers file as the owner of value, found at path, and of everything within it.
+func (m *fragmentMerger) record(value interface{}, file, path string) {
+	if path != "" {
+		m.owners[path] = file
+	}
+	if path == "routing.rules" {
+		rules, _ := value.([]interface{})
+		for _, rule := range rules {
+			if name := ruleName(rule); name != "" {
+				m.rules[name] = file
+			}
+		}
+		return
+	}
+	if object, ok := value.(map[string]interface{}); ok {
+		for key, v := range object {
+			m.record(v, file, joinPath(path, key))
+		}
+	}
+}
+
+// merge adds the values of src, defined in file, to dst. Objects are merged key by key, but pools as a whole,
+// and routing rules are appended; any other value defined in both is a conflict.
+func (m *fragmentMerger) merge(dst, src map[string]interface{}, file, prefix string) error {
+	keys := make([]string, 0, len(src))
+	for key := range src {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, path := src[key], joinPath(prefix, key)
+		if value == nil {
+			continue // Defines nothing
+		}
+		existing, ok := dst[key]
+		if !ok || existing == nil {
+			dst[key] = value
+			m.record(value, file, path)
+			continue
+		}
+		if path == "routing.rules" {
+			if err := m.mergeRules(dst, value, file); err != nil {
+				return err
+			}
+			continue
+		}
+
+		srcObject, srcIsObject := value.(map[string]interface{})
+		dstObject, dstIsObject := existing.(map[string]interface{})
+		if !srcIsObject || !dstIsObject || prefix == "pools" {
+			return fmt.Errorf("conflicting config: %s is defined in both %s and %s", path, m.owner(path), file)
+		}
+		if err := m.merge(dstObject, srcObject, file, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeRules appends the routing rules in value, defined in file, to those of the routing object dst.
+// Rules are keyed by name; unnamed rules never conflict.
+func (m *fragmentMerger) mergeRules(dst map[string]interface{}, value interface{}, file string) error {
+	rules, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("config fragment %s: routing.rules must be a list", file)
+	}
+	merged, _ := dst["rules"].([]interface{})
+	for _, rule := range rules {
+		if name := ruleName(rule); name != "" {
+			if first, ok := m.rules[name]; ok {
+				return fmt.Errorf("conflicting config: routing rule %q is defined in both %s and %s", name, first, file)
+			}
+			m.rules[name] = file
+		}
+		merged = append(merged, rule)
+	}
+	dst["rules"] = merged
+	return nil
+}
+
+// ruleName returns the name of a routing rule in a decoded document, or "" when it has none.
+func ruleName(rule interface{}) string {
+	fields, _ := rule.(map[string]interface{})
+	name, _ := fields["name"].(string)
+	return name
+}
+
+// owner returns the file that defined the value at a path, or the closest parent of it.
+func (m *fragmentMerger) owner(path string) string {
+	for {
+		if file, ok := m.owners[path]; ok {
+			return file
+		}
+		i := strings.LastIndexByte(path, '.')
+		if i < 0 {
+			return ""
+		}
+		path = path[:i]
+	}
+}
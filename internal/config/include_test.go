@@ -0,0 +1,164 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFragments writes a main config file including the fragments directory conf.d, and the fragments.
+// It returns the path of the main config file.
+func writeFragments(t *testing.T, main string, fragments map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "conf.d"), 0o755))
+	for name, content := range fragments {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "conf.d", name), []byte(content), 0o644))
+	}
+	path := filepath.Join(dir, "app-config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(main), 0o644))
+	return path
+}
+
+const fragmentsMain = `
+include_dir: conf.d
+backend:
+  routes: [8081]
+pools:
+  web:
+    backends: ["https://web.internal"]
+routing:
+  rules:
+    - name: static
+      match: {path_prefix: /static/}
+      pool: web
+  default: web
+`
+
+func TestResolve_Fragments(t *testing.T) {
+	path := writeFragments(t, fragmentsMain, map[string]string{
+		"20-admin.json": `{
+  "pools": {"admin": {"backends": ["https://admin.internal"], "strategy": "random"}},
+  "routing": {"rules": [{"name": "admin", "match": {"path_prefix": "/admin/"}, "pool": "admin"}]},
+  "backend": {"checks": [{"name": "disk", "type": "disk", "path": "/", "min_free_bytes": 18446744073709551615}]}
+}`,
+		"10-api.yaml": `
+pools:
+  api:
+    backends: ["https://api.internal"]
+routing:
+  rules:
+    - name: api
+      match: {host: api.example.com}
+      pool: api
+health_check:
+  workers: 8
+`,
+		".10-api.yaml.swp": "not a fragment",
+		"README.md":        "not a fragment either",
+	})
+
+	r, err := Resolve([]string{"-config", path}, nil)
+	assert.NoError(t, err)
+	cfg := r.Config
+
+	assert.Equal(t, []string{DefaultPool, "admin", "api", "web"}, cfg.PoolNames())
+	assert.Equal(t, StrategyRandom, cfg.Pools["admin"].Strategy)
+
+	// Rules of the main file come first, then those of the fragments in file name order
+	var names []string
+	for _, rule := range cfg.Routing.Rules {
+		names = append(names, rule.Name)
+	}
+	assert.Equal(t, []string{"static", "api", "admin"}, names)
+	assert.Equal(t, "web", cfg.Routing.Default)
+
+	assert.Equal(t, 8, cfg.HealthCheck.Workers)
+	assert.Equal(t, uint64(18446744073709551615), cfg.Backend.Checks[0].MinFreeBytes)
+	assert.Equal(t, Routes("8081"), cfg.Backend.Routes)
+	assert.Equal(t, LayerFile, r.Source("pools.api.backends"))
+	assert.Equal(t, LayerFile, r.Source("health_check.workers"))
+	assert.NoError(t, cfg.Validate())
+
+	files, err := cfg.FragmentFiles(path)
+	assert.NoError(t, err)
+	dir := filepath.Join(filepath.Dir(path), "conf.d")
+	assert.Equal(t, []string{filepath.Join(dir, "10-api.yaml"), filepath.Join(dir, "20-admin.json")}, files)
+}
+
+func TestResolve_FragmentConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		fragments map[string]string
+		wantErr   []string
+	}{
+		{
+			name: "Pool defined by the main file",
+			fragments: map[string]string{
+				"web.yaml": "pools:\n  web:\n    strategy: random\n",
+			},
+			wantErr: []string{"pools.web is defined in both", "app-config.yaml and", filepath.Join("conf.d", "web.yaml")},
+		},
+		{
+			name: "Pool defined by two fragments",
+			fragments: map[string]string{
+				"a.yaml": "pools:\n  api:\n    backends: [\"https://a.internal\"]\n",
+				"b.yaml": "pools:\n  api:\n    backends: [\"https://b.internal\"]\n",
+			},
+			wantErr: []string{"pools.api is defined in both", filepath.Join("conf.d", "a.yaml"), filepath.Join("conf.d", "b.yaml")},
+		},
+		{
+			name: "Routing rule of the same name",
+			fragments: map[string]string{
+				"static.yaml": "routing:\n  rules:\n    - name: static\n      pool: web\n",
+			},
+			wantErr: []string{`routing rule "static" is defined in both`, "app-config.yaml", "static.yaml"},
+		},
+		{
+			name: "Value set by two files",
+			fragments: map[string]string{
+				"default.json": `{"routing": {"default": "api"}}`,
+			},
+			wantErr: []string{"routing.default is defined in both", "app-config.yaml", "default.json"},
+		},
+		{
+			name: "Nested include",
+			fragments: map[string]string{
+				"nested.yaml": "include_dir: more.d\n",
+			},
+			wantErr: []string{"nested.yaml: include_dir can only be set in the main config file"},
+		},
+		{
+			name: "Invalid fragment",
+			fragments: map[string]string{
+				"typo.yaml": "pools:\n  api:\n    backend: []\n",
+			},
+			wantErr: []string{"failed to decode config fragment", "typo.yaml", `unknown field "backend"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFragments(t, fragmentsMain, tt.fragments)
+			_, err := Resolve([]string{"-config", path}, nil)
+			if assert.Error(t, err) {
+				for _, want := range tt.wantErr {
+					assert.Contains(t, err.Error(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestResolve_FragmentsInvalidDir(t *testing.T) {
+	path := writeConfig(t, "app-config.yaml", "include_dir: missing.d\n")
+	_, err := Resolve([]string{"-config", path}, nil)
+	assert.ErrorContains(t, err, "failed to read include_dir")
+
+	// The fragments are only known once the file is read, so later layers cannot name another directory
+	path = writeConfig(t, "app-config.yaml", "backend:\n  routes: [8081]\n")
+	_, err = Resolve([]string{"-config", path, "-include_dir", "conf.d"}, nil)
+	assert.ErrorContains(t, err, "include_dir can only be set in the config file")
+}
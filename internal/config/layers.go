@@ -0,0 +1,453 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration layers, from the lowest to the highest precedence.
+const (
+	LayerDefault = "default" // Built-in defaults
+	LayerFile    = "file"    // The JSON or YAML config file
+	LayerEnv     = "env"     // RR_* environment variables
+	LayerFlag    = "flag"    // Command-line flags
+)
+
+const envPrefix = "RR_" // Prefix of the environment variables that override config values
+
+// Defaults returns the built-in configuration every other layer is applied on top of.
+func Defaults() *Config {
+	return &Config{
+		Server: Server{
+			Port:    "8080",
+			Timeout: Seconds(10),
+		},
+		Backend: Backend{
+			Endpoint: map[string]Endpoint{
+				HealthcheckEndpoint: {URL: "/health", Timeout: Seconds(2)},
+			},
+		},
+		HealthCheckInterval: Seconds(30),
+		GracefulTimeout:     Seconds(10),
+		HealthCheck: HealthCheck{
+			Workers:            4,
+			UnhealthyInterval:  Seconds(5),
+			JitterPercent:      10,
+			MinHealthyBackends: 1,
+			HistorySize:        50,
+		},
+	}
+}
+
+// Resolved is the effective configuration together with the layer every value came from.
+type Resolved struct {
+	Config *Config
+
+	// File is the path of the config file the configuration was read from.
+	File string
+
+	// DebugConfig reports whether the -debug-config flag asked for a dump of the resolved values.
+	DebugConfig bool
+
+	layers []layerPaths // Field paths set by every layer, in the order the layers were applied
+}
+
+// layerPaths lists the dotted field paths a layer set.
+type layerPaths struct {
+	layer string
+	paths []string
+}
+
+// override is a single value set by an environment variable or a flag.
+type override struct {
+	name  string // Variable or flag that set the value, for error messages
+	path  string // Dotted field path, e.g. "server.port"
+	value string
+}
+
+// Resolve builds the effective configuration from the built-in defaults, the config file,
+// RR_* environment variables and the command-line flags in args, each layer overriding the ones before it.
+// environ holds "KEY=value" pairs as returned by os.Environ.
+//
+// The config file is named by the -config flag, or else by the ROUND_ROBIN_CONF_PATH environment variable.
+// Environment variables map to nested fields by their JSON names, e.g. RR_HEALTH_CHECK_WORKERS sets
+// health_check.workers. Every field also has a flag named by its dotted path, e.g. -server.port,
+// and -set path=value sets any field, including new map entries such as backend.endpoints.status.url.
+func Resolve(args, environ []string) (*Resolved, error) {
+	return ResolveFlags(flag.NewFlagSet("roundrobinator", flag.ContinueOnError), args, environ)
+}
+
+// ResolveFlags works like Resolve, parsing args with fs, which may define flags of its own next to the config flags.
+func ResolveFlags(fs *flag.FlagSet, args, environ []string) (*Resolved, error) {
+	cfg := Defaults()
+	r := &Resolved{Config: cfg}
+
+	// Parse the flags first, as they name the config file; their values are applied last
+	var flags []override
+	configFile := fs.String("config", "", "path of the JSON or YAML config file (overrides "+confPath+")")
+	fs.BoolVar(&r.DebugConfig, "debug-config", false, "log every effective config value and the layer it came from")
+	fs.Func("set", "set any config value as `path=value`, e.g. backend.endpoints.healthcheck.timeout=5", func(s string) error {
+		path, value, ok := strings.Cut(s, "=")
+		if !ok {
+			return fmt.Errorf("expected path=value, got %q", s)
+		}
+		flags = append(flags, override{name: "-set " + path, path: path, value: value})
+		return nil
+	})
+	for _, path := range fieldPaths(reflect.ValueOf(cfg), "") {
+		fs.Func(path, "override "+path, func(value string) error {
+			flags = append(flags, override{name: "-" + path, path: path, value: value})
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			env[key] = value
+		}
+	}
+
+	// Get the config file path from the flag or the environment variable
+	path := *configFile
+	if path == "" {
+		path = env[confPath]
+	}
+	if path == "" {
+		// push alerts
+		return nil, fmt.Errorf("config path not set in environment variable : %s", confPath)
+	}
+	paths, err := loadFileInto(cfg, path)
+	if err != nil {
+		return nil, err
+	}
+	r.File = path
+	r.layers = append(r.layers, layerPaths{layer: LayerFile, paths: paths})
+
+	// Environment variables can name any field of the config as it stands after the file, map entries included
+	envPaths := make(map[string]string)
+	for _, path := range fieldPaths(reflect.ValueOf(cfg), "") {
+		envPaths[envName(path)] = path
+	}
+	var vars []override
+	for key, value := range env {
+		if !strings.HasPrefix(key, envPrefix) {
+			continue
+		}
+		path, ok := envPaths[key]
+		if !ok {
+			log.Printf("Ignoring environment variable %s: it does not name a config field", key)
+			continue
+		}
+		vars = append(vars, override{name: key, path: path, value: value})
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].name < vars[j].name })
+
+	if err := r.apply(LayerEnv, vars); err != nil {
+		return nil, err
+	}
+	if err := r.apply(LayerFlag, flags); err != nil {
+		return nil, err
+	}
+
+	// Resolve secret references last, so any layer can give them
+	if err := cfg.resolveSecrets(env, filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Load resolves the configuration like Resolve and validates the result.
+func Load(args, environ []string) (*Resolved, error) {
+	r, err := Resolve(args, environ)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Config.Validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// apply sets every override on the config and records the paths as set by layer.
+func (r *Resolved) apply(layer string, overrides []override) error {
+	paths := make([]string, 0, len(overrides))
+	for _, o := range overrides {
+		if o.path == "include_dir" {
+			return fmt.Errorf("invalid value for %s: include_dir can only be set in the config file", o.name)
+		}
+		if err := setField(reflect.ValueOf(r.Config), strings.Split(o.path, "."), o.value); err != nil {
+			return fmt.Errorf("invalid value for %s: %v", o.name, err)
+		}
+		paths = append(paths, o.path)
+	}
+	r.layers = append(r.layers, layerPaths{layer: layer, paths: paths})
+	return nil
+}
+
+// Source returns the layer the value at a dotted field path, such as "server.port", came from.
+func (r *Resolved) Source(path string) string {
+	for i := len(r.layers) - 1; i >= 0; i-- {
+		for _, p := range r.layers[i].paths {
+			if p == path || strings.HasPrefix(p, path+".") || strings.HasPrefix(path, p+".") {
+				return r.layers[i].layer
+			}
+		}
+	}
+	return LayerDefault
+}
+
+// Dump writes every effective config value and the layer it came from, one per line.
+// Secret values are redacted.
+func (r *Resolved) Dump(w io.Writer) error {
+	cfg, err := r.Config.Redact()
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	root := reflect.ValueOf(cfg)
+	for _, path := range fieldPaths(root, "") {
+		value, err := json.Marshal(fieldValue(root, strings.Split(path, ".")).Interface())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%s\t%s\t(%s)\n", path, value, r.Source(path))
+	}
+	return tw.Flush()
+}
+
+// loadFileInto decodes a JSON or YAML configuration file on top of cfg
+// and returns the dotted paths of the values it set.
+func loadFileInto(cfg *Config, path string) ([]string, error) {
+	// Read the config file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// push alerts
+		return nil, fmt.Errorf("failed to load config: %v", err)
+	}
+
+	// Unmarshal the content into Config struct
+	format := DetectFormat(path, data)
+	if err := decodeInto(cfg, data, format); err != nil {
+		// push alerts
+		return nil, fmt.Errorf("failed to decode config %s: %v", path, err)
+	}
+
+	doc, err := decodeDocument(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode config %s: %v", path, err)
+	}
+
+	// Merge the fragments of include_dir, and decode the merged document on top of what the file set
+	if object, ok := doc.(map[string]interface{}); ok && cfg.IncludeDir != "" {
+		if err := mergeFragments(path, object, cfg); err != nil {
+			return nil, err
+		}
+		merged, err := json.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+		if err := decodeJSON(cfg, merged); err != nil {
+			return nil, fmt.Errorf("failed to decode config %s with its fragments: %v", path, err)
+		}
+	}
+	return documentPaths(doc, ""), nil
+}
+
+// decodeDocument parses a JSON or YAML configuration document into plain maps, slices and scalars.
+func decodeDocument(data []byte, format string) (interface{}, error) {
+	if format == FormatJSON {
+		// Keep numbers as written, so documents encode back without losing precision
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var doc interface{}
+		err := dec.Decode(&doc)
+		return doc, err
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	conv := &yamlConverter{nodes: make(map[string]*yaml.Node)}
+	return conv.convert(&root, reflect.TypeOf(Config{}), "")
+}
+
+// documentPaths returns the dotted path of every value in a decoded document.
+// Lists are values of their own; their items are not listed.
+func documentPaths(doc interface{}, prefix string) []string {
+	m, ok := doc.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		if prefix == "" {
+			return nil
+		}
+		return []string{prefix}
+	}
+	var paths []string
+	for key, value := range m {
+		paths = append(paths, documentPaths(value, joinPath(prefix, key))...)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// fieldPaths returns the dotted path of every value in v, a config struct or a field of one.
+// Structs and non-empty maps of structs are descended into; everything else, lists included, is a single value.
+func fieldPaths(v reflect.Value, prefix string) []string {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return []string{prefix}
+		}
+		v = v.Elem()
+	}
+	if prefix != "" && !nested(v.Type()) {
+		return []string{prefix}
+	}
+
+	var paths []string
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); field.IsExported() {
+				paths = append(paths, fieldPaths(v.Field(i), joinPath(prefix, jsonName(field)))...)
+			}
+		}
+	case reflect.Map:
+		if v.Len() == 0 {
+			return []string{prefix} // Listed as a whole so it can still be set
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			paths = append(paths, fieldPaths(v.MapIndex(key), joinPath(prefix, key.String()))...)
+		}
+	}
+	return paths
+}
+
+// nested reports whether values of type t are listed field by field rather than as a single value.
+func nested(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return true
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.Struct
+	}
+	return false
+}
+
+// fieldValue returns the value at a field path within v.
+func fieldValue(v reflect.Value, segments []string) reflect.Value {
+	for _, segment := range segments {
+		for v.Kind() == reflect.Pointer {
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			v = structField(v, segment)
+		case reflect.Map:
+			v = v.MapIndex(reflect.ValueOf(segment).Convert(v.Type().Key()))
+		}
+	}
+	return v
+}
+
+// setField parses value as the field at a path within v and stores it there.
+// Missing map entries are created.
+func setField(v reflect.Value, segments []string, value string) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if len(segments) == 0 {
+		return assign(v, value)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if field := structField(v, segments[0]); field.IsValid() && nested(v.Type()) {
+			return setField(field, segments[1:], value)
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		key := reflect.ValueOf(segments[0]).Convert(v.Type().Key())
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := setField(elem, segments[1:], value); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+	}
+	return fmt.Errorf("unknown config field %q", segments[0])
+}
+
+// structField returns the field of struct v with the given JSON name, or the zero Value when there is none.
+func structField(v reflect.Value, name string) reflect.Value {
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Type().Field(i); field.IsExported() && jsonName(field) == name {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// assign parses value as YAML or JSON of v's type and stores it in v.
+// Lists may also be written without brackets, as comma separated items.
+func assign(v reflect.Value, value string) error {
+	if v.Kind() == reflect.String {
+		v.SetString(value)
+		return nil
+	}
+	if v.Kind() == reflect.Slice && !strings.HasPrefix(strings.TrimSpace(value), "[") {
+		value = "[" + value + "]"
+	}
+
+	parsed := reflect.New(v.Type())
+	if err := decodeYAML(parsed.Interface(), []byte(value)); err != nil {
+		return err
+	}
+	v.Set(parsed.Elem())
+	return nil
+}
+
+// envName returns the environment variable that overrides the field at a dotted path,
+// e.g. RR_HEALTH_CHECK_WORKERS for health_check.workers.
+func envName(path string) string {
+	return envPrefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, path)
+}
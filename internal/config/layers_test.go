@@ -0,0 +1,153 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeConfig writes content to a config file named name in a temporary directory and returns its path.
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestResolve_LayerPrecedence(t *testing.T) {
+	path := writeConfig(t, "app-config.yaml", `
+server:
+  port: 9090
+  timeout: 20
+backend:
+  routes: [8081]
+health_check:
+  workers: 8
+`)
+
+	environ := []string{
+		confPath + "=" + path,
+		"RR_SERVER_TIMEOUT=30",
+		"RR_HEALTHCHECK_TICKER_TIME_SECONDS=1m30s",
+		"RR_HEALTH_CHECK_WORKERS=16",
+		"RR_BACKEND_ROUTES=8081,https://api.internal:8443",
+		"RR_BACKEND_ENDPOINTS_HEALTHCHECK_TIMEOUT=3",
+		"HOME=/root",
+	}
+	args := []string{"-health_check.workers=32", "-set", "backend.endpoints.status.url=/status", "-server.policy.connect_timeout=250ms"}
+
+	r, err := Resolve(args, environ)
+	assert.NoError(t, err)
+	cfg := r.Config
+
+	assert.Equal(t, "9090", cfg.Server.Port)
+	assert.Equal(t, 30*time.Second, cfg.Server.Timeout.Duration())
+	assert.Equal(t, 250*time.Millisecond, cfg.Server.Policy.ConnectTimeout.Duration())
+	assert.Equal(t, 90*time.Second, cfg.HealthCheckInterval.Duration())
+	assert.Equal(t, 32, cfg.HealthCheck.Workers)
+	assert.Equal(t, Routes("8081", "https://api.internal:8443"), cfg.Backend.Routes)
+	assert.Equal(t, Endpoint{URL: "/health", Timeout: Seconds(3)}, cfg.Backend.Endpoint[HealthcheckEndpoint])
+	assert.Equal(t, Endpoint{URL: "/status"}, cfg.Backend.Endpoint["status"])
+	assert.Equal(t, Seconds(10), cfg.GracefulTimeout)
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "graceful_timeout_seconds", expected: LayerDefault},
+		{path: "backend.endpoints.healthcheck.url", expected: LayerDefault},
+		{path: "server.port", expected: LayerFile},
+		{path: "server.timeout", expected: LayerEnv},
+		{path: "backend.routes", expected: LayerEnv},
+		{path: "backend.endpoints.healthcheck.timeout", expected: LayerEnv},
+		{path: "health_check.workers", expected: LayerFlag},
+		{path: "backend.endpoints.status.url", expected: LayerFlag},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, r.Source(tt.path), tt.path)
+	}
+}
+
+func TestResolve_ConfigFlag(t *testing.T) {
+	path := writeConfig(t, "app-config.json", `{"server": {"port": "7070"}}`)
+
+	r, err := Resolve([]string{"-config", path}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "7070", r.Config.Server.Port)
+	assert.Equal(t, Defaults().HealthCheck, r.Config.HealthCheck)
+}
+
+func TestResolve_Errors(t *testing.T) {
+	path := writeConfig(t, "app-config.json", `{}`)
+
+	tests := []struct {
+		name          string
+		args          []string
+		environ       []string
+		expectedError string
+	}{
+		{
+			name:          "MissingConfigPath",
+			expectedError: "config path not set",
+		},
+		{
+			name:          "InvalidEnvValue",
+			environ:       []string{confPath + "=" + path, "RR_SERVER_TIMEOUT=soon"},
+			expectedError: "invalid value for RR_SERVER_TIMEOUT",
+		},
+		{
+			name:          "InvalidFlagValue",
+			args:          []string{"-config", path, "-health_check.jitter_percent=lots"},
+			expectedError: "invalid value for -health_check.jitter_percent",
+		},
+		{
+			name:          "UnknownSetPath",
+			args:          []string{"-config", path, "-set", "server.address=localhost"},
+			expectedError: `invalid value for -set server.address: unknown config field "address"`,
+		},
+		{
+			name:          "UnknownFlag",
+			args:          []string{"-config", path, "-server.address=localhost"},
+			expectedError: "flag provided but not defined: -server.address",
+		},
+		{
+			name:          "UnexpectedArguments",
+			args:          []string{"-config", path, "serve"},
+			expectedError: "unexpected arguments: serve",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Resolve(tt.args, tt.environ)
+			assert.Nil(t, r)
+			assert.ErrorContains(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestResolved_Dump(t *testing.T) {
+	path := writeConfig(t, "app-config.json", `{"server": {"port": "9090"}}`)
+
+	r, err := Resolve([]string{"-config", path, "-debug-config"}, []string{"RR_GRACEFUL_TIMEOUT_SECONDS=5"})
+	assert.NoError(t, err)
+	assert.True(t, r.DebugConfig)
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.Dump(&buf))
+	dump := buf.String()
+	assert.Regexp(t, `(?m)^server\.port +"9090" +\(file\)$`, dump)
+	assert.Regexp(t, `(?m)^server\.timeout +"10s" +\(default\)$`, dump)
+	assert.Regexp(t, `(?m)^graceful_timeout_seconds +"5s" +\(env\)$`, dump)
+	assert.Regexp(t, `(?m)^backend\.endpoints\.healthcheck\.url +"/health" +\(default\)$`, dump)
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "RR_HEALTH_CHECK_WORKERS", envName("health_check.workers"))
+	assert.Equal(t, "RR_HEALTHCHECK_TICKER_TIME_SECONDS", envName("healthCheck_ticker_time_seconds"))
+	assert.Equal(t, "RR_HEALTH_CHECK_PROBES_8081_TIMEOUT", envName("health_check.probes.8081.timeout"))
+}
@@ -0,0 +1,86 @@
+package config
+
+// Policy holds the limits applied to requests forwarded to backends. Zero values are unset:
+// they inherit the value of the enclosing level, and disable the limit when no level sets it.
+// Policies are resolved from server.policy, then the pool, then the routing rule matching the request.
+type Policy struct {
+	// Timeout specifies the timeout of the whole request, including reading the response body.
+	// In server.policy, a value of 0 falls back to server.timeout.
+	Timeout Duration `json:"timeout"`
+
+	// ConnectTimeout specifies the timeout for establishing the connection to a backend.
+	ConnectTimeout Duration `json:"connect_timeout"`
+
+	// ResponseHeaderTimeout specifies the timeout for the response headers once the request is sent.
+	ResponseHeaderTimeout Duration `json:"response_header_timeout"`
+
+	// MaxBodyBytes is the largest request body accepted; larger requests are answered with 413.
+	MaxBodyBytes int64 `json:"max_body_bytes"`
+
+	// Retry controls how failed requests are retried.
+	Retry Retry `json:"retry"`
+}
+
+// Retry represents the retry policy of forwarded requests. Retries go to another backend than the attempts before.
+// Idempotent requests are retried; other requests only when the backend could not be connected to, so it got nothing.
+type Retry struct {
+	// Attempts is the number of times a request is tried in total, the first one included.
+	// Values below 2 disable retries.
+	Attempts int `json:"attempts"`
+
+	// Statuses lists the backend response status codes that are retried, e.g. [502, 503, 504].
+	// Requests that fail to reach a backend are always retried.
+	Statuses []int `json:"statuses"`
+
+	// Backoff is the longest wait before the first retry, doubled for every next one; the actual wait is
+	// picked at random up to it. 0 retries at once.
+	Backoff Duration `json:"backoff"`
+
+	// MaxBackoff caps the longest wait before a retry. 0 leaves it uncapped.
+	MaxBackoff Duration `json:"max_backoff"`
+
+	// BudgetPercent caps the retries of a pool at a percentage of its requests, so retries cannot pile up on
+	// backends that are failing anyway. It is set in server.policy or per pool, not per routing rule. 0 is unlimited.
+	BudgetPercent int `json:"budget_percent"`
+}
+
+// Merge returns the policy with the values set in over replacing its own.
+func (p Policy) Merge(over Policy) Policy {
+	if over.Timeout != 0 {
+		p.Timeout = over.Timeout
+	}
+	if over.ConnectTimeout != 0 {
+		p.ConnectTimeout = over.ConnectTimeout
+	}
+	if over.ResponseHeaderTimeout != 0 {
+		p.ResponseHeaderTimeout = over.ResponseHeaderTimeout
+	}
+	if over.MaxBodyBytes != 0 {
+		p.MaxBodyBytes = over.MaxBodyBytes
+	}
+	if over.Retry.Attempts != 0 {
+		p.Retry.Attempts = over.Retry.Attempts
+	}
+	if over.Retry.Statuses != nil {
+		p.Retry.Statuses = over.Retry.Statuses
+	}
+	if over.Retry.Backoff != 0 {
+		p.Retry.Backoff = over.Retry.Backoff
+	}
+	if over.Retry.MaxBackoff != 0 {
+		p.Retry.MaxBackoff = over.Retry.MaxBackoff
+	}
+	if over.Retry.BudgetPercent != 0 {
+		p.Retry.BudgetPercent = over.Retry.BudgetPercent
+	}
+	return p
+}
+
+// ServerPolicy returns the server-wide policy, with its timeout falling back to server.timeout.
+func (c *Config) ServerPolicy() Policy {
+	policy := c.Server.Policy
+	if policy.Timeout == 0 {
+		policy.Timeout = c.Server.Timeout
+	}
+	return policy
+}
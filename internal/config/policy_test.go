@@ -0,0 +1,34 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Merge(t *testing.T) {
+	base := Policy{Timeout: Seconds(10), ConnectTimeout: Seconds(2), MaxBodyBytes: 1 << 20, Retry: Retry{Attempts: 3, Statuses: []int{502, 503}}}
+
+	assert.Equal(t, base, base.Merge(Policy{}))
+	assert.Equal(t,
+		Policy{Timeout: Seconds(30), ConnectTimeout: Seconds(2), ResponseHeaderTimeout: Seconds(5), MaxBodyBytes: 1 << 20, Retry: Retry{Attempts: 1, Statuses: []int{502, 503}}},
+		base.Merge(Policy{Timeout: Seconds(30), ResponseHeaderTimeout: Seconds(5), Retry: Retry{Attempts: 1}}),
+	)
+	assert.Equal(t, []int{}, base.Merge(Policy{Retry: Retry{Statuses: []int{}}}).Retry.Statuses)
+
+	backoff := Retry{Backoff: Duration(100 * time.Millisecond), MaxBackoff: Seconds(2), BudgetPercent: 20}
+	assert.Equal(t,
+		Retry{Attempts: 3, Statuses: []int{502, 503}, Backoff: Duration(100 * time.Millisecond), MaxBackoff: Seconds(2), BudgetPercent: 20},
+		base.Merge(Policy{Retry: backoff}).Retry,
+	)
+	assert.Equal(t, backoff, Policy{Retry: backoff}.Merge(Policy{}).Retry)
+}
+
+func TestConfig_ServerPolicy(t *testing.T) {
+	cfg := &Config{Server: Server{Timeout: Seconds(10), Policy: Policy{ConnectTimeout: Seconds(1)}}}
+	assert.Equal(t, Policy{Timeout: Seconds(10), ConnectTimeout: Seconds(1)}, cfg.ServerPolicy())
+
+	cfg.Server.Policy.Timeout = Seconds(20)
+	assert.Equal(t, Seconds(20), cfg.ServerPolicy().Timeout)
+}
@@ -0,0 +1,124 @@
+package config
+
+import "sort"
+
+// DefaultPool is the name of the pool made of backend.routes, which the legacy /route endpoint forwards to.
+const DefaultPool = "default"
+
+// Load balancing strategies of a pool.
+const (
+	StrategyRoundRobin = "round_robin" // Smooth weighted round-robin
+	StrategyRandom     = "random"      // Weighted random choice
+)
+
+// Pool is a named set of backends with its own balancing strategy, health check and forwarding policy.
+type Pool struct {
+	// Backends lists the backends of the pool, in the same forms as backend.routes.
+	Backends []Route `json:"backends"`
+
+	// Strategy selects how a backend is picked for every request: "round_robin" (default) or "random".
+	Strategy string `json:"strategy"`
+
+	// HealthCheck is the health endpoint path and timeout used to probe the backends of the pool.
+	// Empty values fall back to the healthcheck entry of backend.endpoints.
+	HealthCheck Endpoint `json:"health_check"`
+
+	// Policy overrides server.policy for requests forwarded to the pool.
+	Policy Policy `json:"policy"`
+}
+
+// Routing represents the routing table that sends requests to pools.
+type Routing struct {
+	// Rules are tried in order; the first one matching a request picks its pool.
+	Rules []RoutingRule `json:"rules"`
+
+	// Default is the pool of requests no rule matches. When empty, they are answered with 404.
+	Default string `json:"default"`
+}
+
+// RoutingRule sends the requests it matches to a pool.
+type RoutingRule struct {
+	// Name identifies the rule in logs and errors.
+	Name string `json:"name"`
+
+	// Match holds the conditions a request must meet; empty conditions match every request.
+	Match Match `json:"match"`
+
+	// Pool is the name of the pool matching requests are forwarded to.
+	Pool string `json:"pool"`
+
+	// Policy overrides the policy of the pool for matching requests.
+	Policy Policy `json:"policy"`
+
+	// StripPrefix is removed from the start of the path of matching requests before they are forwarded,
+	// when the path starts with it at a segment boundary, e.g. "/billing" turns "/billing/invoices" into "/invoices".
+	StripPrefix string `json:"strip_prefix"`
+
+	// AddPrefix is put in front of the path of matching requests, after StripPrefix is removed.
+	AddPrefix string `json:"add_prefix"`
+}
+
+// Match holds the conditions of a routing rule. A request matches when it meets all of them.
+type Match struct {
+	// Host is the request host, without port. A leading "*." matches any subdomain, e.g. "*.example.com".
+	Host string `json:"host"`
+
+	// PathPrefix is a prefix the request path must start with, ending there or at a '/'.
+	PathPrefix string `json:"path_prefix"`
+
+	// PathRegex is a regular expression the request path must match.
+	PathRegex string `json:"path_regex"`
+
+	// Methods lists the request methods accepted, e.g. ["GET", "HEAD"].
+	Methods []string `json:"methods"`
+
+	// Headers maps header names to the value the request must carry.
+	Headers map[string]string `json:"headers"`
+}
+
+// BackendPools returns every pool of backends by name: the configured pools plus, when backend.routes is set,
+// the default pool made of it. Empty settings are resolved, so every pool has a strategy, a health check
+// and a policy merged over server.policy.
+func (c *Config) BackendPools() map[string]Pool {
+	healthcheck := c.Backend.Endpoint[HealthcheckEndpoint]
+	policy := c.ServerPolicy()
+
+	pools := make(map[string]Pool, len(c.Pools)+1)
+	for name, pool := range c.Pools {
+		pools[name] = pool
+	}
+	if len(c.Backend.Routes) > 0 {
+		pools[DefaultPool] = Pool{Backends: c.Backend.Routes} // Validation rejects a configured pool of the same name
+	}
+
+	for name, pool := range pools {
+		if pool.Strategy == "" {
+			pool.Strategy = StrategyRoundRobin
+		}
+		if pool.HealthCheck.URL == "" {
+			pool.HealthCheck.URL = healthcheck.URL
+		}
+		if pool.HealthCheck.Timeout == 0 {
+			pool.HealthCheck.Timeout = healthcheck.Timeout
+		}
+		pool.Policy = policy.Merge(pool.Policy)
+		pools[name] = pool
+	}
+	return pools
+}
+
+// PoolNames returns the names of BackendPools, the default pool first and the others in name order.
+func (c *Config) PoolNames() []string {
+	pools := c.BackendPools()
+	names := make([]string, 0, len(pools))
+	for name := range pools {
+		if name != DefaultPool {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := pools[DefaultPool]; ok {
+		names = append([]string{DefaultPool}, names...)
+	}
+	return names
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendPools(t *testing.T) {
+	cfg := &Config{
+		Server: Server{Timeout: Seconds(10), Policy: Policy{ConnectTimeout: Seconds(1), Retry: Retry{Attempts: 2}}},
+		Backend: Backend{
+			Routes:   Routes("8081", "8082"),
+			Endpoint: map[string]Endpoint{HealthcheckEndpoint: {URL: "/health", Timeout: Seconds(2)}},
+		},
+		Pools: map[string]Pool{
+			"web": {Backends: Routes("https://web.internal")},
+			"api": {
+				Backends:    Routes("https://api.internal"),
+				Strategy:    StrategyRandom,
+				HealthCheck: Endpoint{URL: "/status"},
+				Policy:      Policy{Timeout: Seconds(30), MaxBodyBytes: 1024},
+			},
+		},
+	}
+
+	assert.Equal(t, map[string]Pool{
+		DefaultPool: {
+			Backends:    Routes("8081", "8082"),
+			Strategy:    StrategyRoundRobin,
+			HealthCheck: Endpoint{URL: "/health", Timeout: Seconds(2)},
+			Policy:      Policy{Timeout: Seconds(10), ConnectTimeout: Seconds(1), Retry: Retry{Attempts: 2}},
+		},
+		"web": {
+			Backends:    Routes("https://web.internal"),
+			Strategy:    StrategyRoundRobin,
+			HealthCheck: Endpoint{URL: "/health", Timeout: Seconds(2)},
+			Policy:      Policy{Timeout: Seconds(10), ConnectTimeout: Seconds(1), Retry: Retry{Attempts: 2}},
+		},
+		"api": {
+			Backends:    Routes("https://api.internal"),
+			Strategy:    StrategyRandom,
+			HealthCheck: Endpoint{URL: "/status", Timeout: Seconds(2)},
+			Policy:      Policy{Timeout: Seconds(30), ConnectTimeout: Seconds(1), MaxBodyBytes: 1024, Retry: Retry{Attempts: 2}},
+		},
+	}, cfg.BackendPools())
+	assert.Equal(t, []string{DefaultPool, "api", "web"}, cfg.PoolNames())
+
+	// Without backend.routes there is no default pool
+	cfg.Backend.Routes = nil
+	assert.Equal(t, []string{"api", "web"}, cfg.PoolNames())
+}
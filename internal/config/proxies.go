@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// ParseNetwork parses a trusted proxy entry: an IP address, standing for itself, or a CIDR network.
+func ParseNetwork(entry string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(entry); err == nil {
+		return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(entry)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("must be an IP address or a CIDR network, got %q", entry)
+	}
+	if prefix.Addr().Is4In6() {
+		// Match IPv4 clients, whose addresses are unmapped, by their IPv4 network
+		bits := prefix.Bits() - 96
+		if bits < 0 {
+			bits = 0
+		}
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), bits)
+	}
+	return prefix.Masked(), nil
+}
+
+// TrustedNetworks returns the networks of the trusted proxies. Invalid entries, which validation rejects, are skipped.
+func (s Server) TrustedNetworks() []netip.Prefix {
+	var networks []netip.Prefix
+	for _, entry := range s.TrustedProxies {
+		if network, err := ParseNetwork(entry); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
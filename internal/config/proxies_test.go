@@ -0,0 +1,20 @@
+package config
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrustedNetworks(t *testing.T) {
+	server := Server{TrustedProxies: []string{"10.1.2.3", "192.168.7.9/16", "::ffff:172.16.0.0/108", "2001:db8::/32", "invalid"}}
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.1.2.3/32"),
+		netip.MustParsePrefix("192.168.0.0/16"),
+		netip.MustParsePrefix("172.16.0.0/12"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}, server.TrustedNetworks())
+
+	assert.Empty(t, Server{}.TrustedNetworks())
+}
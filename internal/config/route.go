@@ -0,0 +1,116 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Route is a single backend of the Round Robin API.
+// In the configuration it is either a string, holding a bare port served on localhost ("8081")
+// or a full URL ("https://api.internal:8443/v1"), or an object with the URL and backend metadata.
+type Route struct {
+	// URL is the bare port or the base URL of the backend: scheme, host, port and optional base path.
+	URL string `json:"url"`
+
+	// Weight is the relative share of traffic the backend receives. A value of 0 is treated as 1.
+	Weight int `json:"weight,omitempty"`
+
+	// Zone is the availability zone or location of the backend.
+	Zone string `json:"zone,omitempty"`
+
+	// Labels hold free-form metadata about the backend.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// routeFields mirrors Route without its JSON methods, to decode and encode the object form.
+type routeFields Route
+
+// UnmarshalJSON accepts both the string and the object form of a route.
+// A bare number is accepted as a port, as unquoted YAML ports decode to one.
+func (r *Route) UnmarshalJSON(data []byte) error {
+	var url string
+	if err := json.Unmarshal(data, &url); err == nil {
+		*r = Route{URL: url}
+		return nil
+	}
+
+	var port uint16
+	if err := json.Unmarshal(data, &port); err == nil {
+		*r = Route{URL: strconv.Itoa(int(port))}
+		return nil
+	}
+
+	var fields routeFields
+	if err := unmarshalStrict(data, &fields); err != nil {
+		return fmt.Errorf("route must be a port, a URL or an object with a url: %v", err)
+	}
+	*r = Route(fields)
+	return nil
+}
+
+// MarshalJSON writes routes without metadata in the string form.
+func (r Route) MarshalJSON() ([]byte, error) {
+	if r.Weight == 0 && r.Zone == "" && len(r.Labels) == 0 {
+		return json.Marshal(r.URL)
+	}
+	return json.Marshal(routeFields(r))
+}
+
+// Name identifies the backend in health checks, logs and the balancer: the port or URL exactly as configured.
+func (r Route) Name() string {
+	return r.URL
+}
+
+// Port returns the port of a bare-port route, which is served on localhost.
+// ok is false for routes given as a full URL.
+func (r Route) Port() (port string, ok bool) {
+	if isPort(r.URL) {
+		return r.URL, true
+	}
+	return "", false
+}
+
+// BaseURL returns the URL requests to the backend are built on, without a trailing slash.
+func (r Route) BaseURL() string {
+	return BackendURL(r.URL)
+}
+
+// EffectiveWeight returns the configured weight, treating 0 as 1.
+func (r Route) EffectiveWeight() int {
+	if r.Weight <= 0 {
+		return 1
+	}
+	return r.Weight
+}
+
+// BackendURL returns the base URL of a backend from its route name: a bare port is served on localhost.
+func BackendURL(name string) string {
+	if isPort(name) {
+		return "http://localhost:" + name
+	}
+	return strings.TrimRight(name, "/")
+}
+
+// Routes returns routes for the given ports or URLs, without metadata.
+func Routes(urls ...string) []Route {
+	routes := make([]Route, len(urls))
+	for i, url := range urls {
+		routes[i] = Route{URL: url}
+	}
+	return routes
+}
+
+// isPort reports whether s is made only of digits.
+func isPort(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
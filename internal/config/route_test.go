@@ -0,0 +1,84 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoute_UnmarshalJSON(t *testing.T) {
+	var backend Backend
+	err := json.Unmarshal([]byte(`{
+		"routes": [
+			"8081",
+			"https://api.internal:8443/v1/",
+			{"url": "http://10.0.0.7:9000", "weight": 3, "zone": "eu-west-1b", "labels": {"tier": "gold"}}
+		]
+	}`), &backend)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Route{
+		{URL: "8081"},
+		{URL: "https://api.internal:8443/v1/"},
+		{URL: "http://10.0.0.7:9000", Weight: 3, Zone: "eu-west-1b", Labels: map[string]string{"tier": "gold"}},
+	}, backend.Routes)
+
+	// Bare numbers are ports
+	assert.NoError(t, json.Unmarshal([]byte(`{"routes": [8081]}`), &backend))
+	assert.Equal(t, []Route{{URL: "8081"}}, backend.Routes)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"routes": [true]}`), &backend))
+}
+
+func TestRoute_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal([]Route{
+		{URL: "8081"},
+		{URL: "http://10.0.0.7:9000", Weight: 3},
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `["8081", {"url": "http://10.0.0.7:9000", "weight": 3}]`, string(data))
+}
+
+func TestRoute_Accessors(t *testing.T) {
+	tests := []struct {
+		name            string
+		route           Route
+		expectedPort    string
+		expectedLocal   bool
+		expectedBaseURL string
+		expectedWeight  int
+	}{
+		{
+			name:            "Bare port",
+			route:           Route{URL: "8081"},
+			expectedPort:    "8081",
+			expectedLocal:   true,
+			expectedBaseURL: "http://localhost:8081",
+			expectedWeight:  1,
+		},
+		{
+			name:            "HTTPS URL with base path",
+			route:           Route{URL: "https://api.internal:8443/v1/", Weight: 5},
+			expectedBaseURL: "https://api.internal:8443/v1",
+			expectedWeight:  5,
+		},
+		{
+			name:            "Host without port",
+			route:           Route{URL: "http://mirror.internal"},
+			expectedBaseURL: "http://mirror.internal",
+			expectedWeight:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port, local := tt.route.Port()
+			assert.Equal(t, tt.expectedPort, port)
+			assert.Equal(t, tt.expectedLocal, local)
+			assert.Equal(t, tt.expectedBaseURL, tt.route.BaseURL())
+			assert.Equal(t, tt.expectedWeight, tt.route.EffectiveWeight())
+			assert.Equal(t, tt.route.URL, tt.route.Name())
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//go:generate go run ./schemagen
+
+// SchemaFile is the name of the generated JSON Schema of the configuration, next to this file.
+const SchemaFile = "app-config.schema.json"
+
+//go:embed app-config.schema.json
+var schema []byte
+
+// Schema returns the JSON Schema of the configuration, as generated by GenerateSchema when the binary was built.
+func Schema() []byte {
+	return schema
+}
+
+// durationPattern matches the strings a Duration accepts: a number of seconds or a Go duration.
+const durationPattern = `^-?([0-9]+(\.[0-9]*)?|\.[0-9]+)((ns|us|µs|ms|s|m|h)(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h))*)?$`
+
+// schemaEnums lists the values accepted by string fields with a fixed set of values, keyed by "Type.Field".
+var schemaEnums = map[string][]string{
+	"Pool.Strategy":        {StrategyRoundRobin, StrategyRandom},
+	"Probe.Type":           {"http", "exec"},
+	"DependencyCheck.Type": {"disk", "http"},
+}
+
+// schemaFallbacks lists the values used for fields left empty that Defaults does not set, keyed by "Type.Field".
+var schemaFallbacks = map[string]interface{}{
+	"Pool.Strategy": StrategyRoundRobin,
+	"Probe.Type":    "http",
+}
+
+var (
+	durationType = reflect.TypeOf(Duration(0))
+	routeType    = reflect.TypeOf(Route{})
+)
+
+// GenerateSchema builds the JSON Schema of the configuration from the Config struct tree.
+// Descriptions are taken from the doc comments of the fields in the source of this package, found in dir,
+// and defaults from Defaults.
+func GenerateSchema(dir string) ([]byte, error) {
+	docs, err := fieldDocs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &schemaGenerator{docs: docs}
+	root := g.schemaOf(reflect.TypeOf(Config{}), reflect.ValueOf(*Defaults()))
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = "Roundrobinator configuration"
+	root["description"] = docs["Config"]
+	root["$defs"] = g.definitions()
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// fieldDocs returns the doc comments of the types in the Go files of dir, keyed by type name,
+// and of the fields of their structs, keyed by "Type.Field".
+func fieldDocs(dir string) (map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make(map[string]string)
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", file, err)
+		}
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				doc := typeSpec.Doc
+				if doc == nil {
+					doc = gen.Doc
+				}
+				docs[typeSpec.Name.Name] = commentText(doc)
+
+				st, ok := typeSpec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				for _, field := range st.Fields.List {
+					for _, name := range field.Names {
+						docs[typeSpec.Name.Name+"."+name.Name] = fieldDoc(field, name.Name)
+					}
+				}
+			}
+		}
+	}
+	return docs, nil
+}
+
+// fieldDoc returns the doc comment of a struct field, or else its line comment.
+// Doc comments start with the Go name of the field, which config files know by its JSON name.
+func fieldDoc(field *ast.Field, name string) string {
+	if field.Doc == nil {
+		return commentText(field.Comment)
+	}
+	doc := commentText(field.Doc)
+	if field.Tag == nil {
+		return doc
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return doc
+	}
+	if jsonName, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ","); jsonName != "" {
+		if rest, ok := strings.CutPrefix(doc, name+" "); ok {
+			doc = jsonName + " " + rest
+		}
+	}
+	return doc
+}
+
+// commentText returns the text of a comment group on a single line.
+func commentText(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
+
+// schemaGenerator builds the schemas of the config types.
+type schemaGenerator struct {
+	docs map[string]string // Doc comments, as returned by fieldDocs
+}
+
+// definitions returns the schemas of the types with their own JSON decoding, which fields refer to.
+func (g *schemaGenerator) definitions() map[string]interface{} {
+	route := g.structSchema(routeType, reflect.Value{})
+	route["required"] = []string{"url"}
+	return map[string]interface{}{
+		"duration": map[string]interface{}{
+			"description": g.docs["Duration"],
+			"type":        []string{"string", "number"},
+			"pattern":     durationPattern,
+		},
+		"route": map[string]interface{}{
+			"description": g.docs["Route"],
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "string", "minLength": 1},
+				map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 65535},
+				route,
+			},
+		},
+	}
+}
+
+// schemaOf returns the schema of values of type t. def holds the default value of the field,
+// and is the zero Value within maps and lists, which have no defaults.
+func (g *schemaGenerator) schemaOf(t reflect.Type, def reflect.Value) map[string]interface{} {
+	switch t {
+	case durationType:
+		return map[string]interface{}{"$ref": "#/$defs/duration"}
+	case routeType:
+		return map[string]interface{}{"$ref": "#/$defs/route"}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.structSchema(t, def)
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": g.schemaOf(t.Elem(), reflect.Value{}),
+		}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": g.schemaOf(t.Elem(), reflect.Value{}),
+		}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+	return map[string]interface{}{}
+}
+
+// structSchema returns the schema of a struct type, which admits no other fields than its own.
+func (g *schemaGenerator) structSchema(t reflect.Type, def reflect.Value) map[string]interface{} {
+	properties := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		var fieldDef reflect.Value
+		if def.IsValid() {
+			fieldDef = def.Field(i)
+		}
+		properties[jsonName(field)] = g.fieldSchema(t.Name(), field, fieldDef)
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// fieldSchema returns the schema of a field of the struct named owner, with its description, enum and default.
+func (g *schemaGenerator) fieldSchema(owner string, field reflect.StructField, def reflect.Value) map[string]interface{} {
+	s := g.schemaOf(field.Type, def)
+	key := owner + "." + field.Name
+	if doc := g.docs[key]; doc != "" {
+		s["description"] = doc
+	}
+	if field.Tag.Get(secretTag) == "true" {
+		s["writeOnly"] = true
+	}
+	if enum, ok := schemaEnums[key]; ok {
+		s["enum"] = enum
+	}
+	if fallback, ok := schemaFallbacks[key]; ok {
+		s["default"] = fallback
+	} else if def.IsValid() && !def.IsZero() && field.Type.Kind() != reflect.Struct {
+		s["default"] = def.Interface()
+	}
+	return s
+}
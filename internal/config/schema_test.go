@@ -0,0 +1,61 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchema_UpToDate(t *testing.T) {
+	generated, err := GenerateSchema(".")
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(generated, Schema()),
+		SchemaFile+" is stale: run go generate ./internal/config and commit the result")
+}
+
+func TestGenerateSchema(t *testing.T) {
+	data, err := GenerateSchema(".")
+	assert.NoError(t, err)
+
+	var schema map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &schema))
+	property := func(path ...string) map[string]interface{} {
+		s := schema
+		for _, name := range path {
+			if name == "*" {
+				s = s["additionalProperties"].(map[string]interface{})
+				continue
+			}
+			s = s["properties"].(map[string]interface{})[name].(map[string]interface{})
+		}
+		return s
+	}
+
+	assert.Equal(t, false, schema["additionalProperties"])
+
+	// Descriptions come from the doc comments, with the JSON name of the field
+	port := property("server", "port")
+	assert.Equal(t, "string", port["type"])
+	assert.Equal(t, "8080", port["default"])
+	assert.Equal(t, "The port on which the server should listen", port["description"])
+	assert.Contains(t, property("health_check", "workers")["description"], "workers bounds how many health probes")
+
+	// Durations and routes accept every form their decoding does
+	timeout := property("server", "timeout")
+	assert.Equal(t, "#/$defs/duration", timeout["$ref"])
+	assert.Equal(t, "10s", timeout["default"])
+	assert.Equal(t, "#/$defs/route", property("backend", "routes")["items"].(map[string]interface{})["$ref"])
+	defs := schema["$defs"].(map[string]interface{})
+	assert.Len(t, defs["route"].(map[string]interface{})["oneOf"], 3)
+
+	strategy := property("pools", "*", "strategy")
+	assert.Equal(t, []interface{}{StrategyRoundRobin, StrategyRandom}, strategy["enum"])
+	assert.Equal(t, StrategyRoundRobin, strategy["default"])
+	assert.Equal(t, []interface{}{"http", "exec"}, property("health_check", "probes", "*", "type")["enum"])
+
+	assert.Equal(t, map[string]interface{}{
+		HealthcheckEndpoint: map[string]interface{}{"url": "/health", "timeout": "2s"},
+	}, property("backend", "endpoints")["default"])
+}
@@ -0,0 +1,20 @@
+// Command schemagen writes the JSON Schema of the configuration to config.SchemaFile.
+// It is run by go generate in the config package directory.
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+)
+
+func main() {
+	data, err := config.GenerateSchema(".")
+	if err != nil {
+		log.Fatalf("Failed to generate the config schema: %v", err)
+	}
+	if err := os.WriteFile(config.SchemaFile, data, 0o644); err != nil {
+		log.Fatalf("Failed to write the config schema: %v", err)
+	}
+}
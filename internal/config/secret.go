@@ -0,0 +1,128 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Prefixes of the references secret values can be given as instead of their value.
+const (
+	secretEnvPrefix  = "env:"  // env:NAME reads the environment variable NAME
+	secretFilePrefix = "file:" // file:/path reads a file, relative to the config file; a trailing newline is dropped
+)
+
+// Redacted replaces the values of secret fields in the output of Redact.
+const Redacted = "[redacted]"
+
+// Struct fields tagged `secret:"true"` hold secrets: strings, or maps or lists of strings, whose values can be given
+// as env: and file: references, and which are redacted from printed configurations and logs.
+const secretTag = "secret"
+
+// resolveSecrets replaces every env: and file: reference in the secret fields of c with the value it names.
+// env holds the environment, and relative files are read from dir.
+func (c *Config) resolveSecrets(env map[string]string, dir string) error {
+	return walkSecrets(reflect.ValueOf(c), "", func(path, value string) (string, error) {
+		if name, ok := strings.CutPrefix(value, secretEnvPrefix); ok {
+			secret, ok := env[name]
+			if !ok {
+				return "", fmt.Errorf("secret %s: environment variable %s is not set", path, name)
+			}
+			return secret, nil
+		}
+		if file, ok := strings.CutPrefix(value, secretFilePrefix); ok {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return "", fmt.Errorf("secret %s: %v", path, err)
+			}
+			return strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r"), nil
+		}
+		return value, nil
+	})
+}
+
+// Redact returns a copy of c with the value of every secret field replaced by Redacted,
+// so it can be printed or logged.
+func (c *Config) Redact() (*Config, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var redacted Config
+	if err := json.Unmarshal(data, &redacted); err != nil {
+		return nil, err
+	}
+	err = walkSecrets(reflect.ValueOf(&redacted), "", func(path, value string) (string, error) {
+		if value == "" {
+			return value, nil
+		}
+		return Redacted, nil
+	})
+	return &redacted, err
+}
+
+// walkSecrets calls fn with the dotted path and the value of every string in the secret fields of v,
+// and stores the value it returns in their place.
+func walkSecrets(v reflect.Value, path string, fn func(path, value string) (string, error)) error {
+	return walkValue(v, path, false, fn)
+}
+
+// walkValue walks v, found at path, for walkSecrets; secret reports whether v is within a secret field.
+func walkValue(v reflect.Value, path string, secret bool, fn func(path, value string) (string, error)) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return walkValue(v.Elem(), path, secret, fn)
+
+	case reflect.String:
+		if !secret {
+			return nil
+		}
+		value, err := fn(path, v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(value)
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldSecret := secret || field.Tag.Get(secretTag) == "true"
+			if err := walkValue(v.Field(i), joinPath(path, jsonName(field)), fieldSecret, fn); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := walkValue(v.Index(i), joinPath(path, strconv.Itoa(i)), secret, fn); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		// Map values are not addressable: walk a copy of every entry and store it back
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(iter.Value())
+			if err := walkValue(elem, joinPath(path, iter.Key().String()), secret, fn); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	}
+	return nil
+}
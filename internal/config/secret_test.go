@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "token"), []byte("from-file\n"), 0o600))
+	env := map[string]string{"PROBE_TOKEN": "from-env"}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr string
+	}{
+		{name: "Literal", value: "plain", want: "plain"},
+		{name: "Environment variable", value: "env:PROBE_TOKEN", want: "from-env"},
+		{name: "Relative file", value: "file:token", want: "from-file"},
+		{name: "Absolute file", value: "file:" + filepath.Join(dir, "token"), want: "from-file"},
+		{name: "Unset variable", value: "env:MISSING", wantErr: "secret health_check.probes.8081.env.TOKEN: environment variable MISSING is not set"},
+		{name: "Missing file", value: "file:missing", wantErr: "secret health_check.probes.8081.env.TOKEN: open"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{HealthCheck: HealthCheck{Probes: map[string]Probe{
+				"8081": {Type: "exec", Command: "check", Args: []string{"env:PROBE_TOKEN"}, Env: map[string]string{"TOKEN": tt.value}},
+			}}}
+			err := cfg.resolveSecrets(env, dir)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.HealthCheck.Probes["8081"].Env["TOKEN"])
+			// Fields not marked secret are left as written
+			assert.Equal(t, []string{"env:PROBE_TOKEN"}, cfg.HealthCheck.Probes["8081"].Args)
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	cfg := &Config{
+		Server: Server{Port: "8080", Timeout: Seconds(10)},
+		HealthCheck: HealthCheck{Probes: map[string]Probe{
+			"8081": {Type: "exec", Command: "check", Env: map[string]string{"TOKEN": "s3cr3t", "EMPTY": ""}},
+		}},
+	}
+
+	redacted, err := cfg.Redact()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"TOKEN": Redacted, "EMPTY": ""}, redacted.HealthCheck.Probes["8081"].Env)
+	assert.Equal(t, "check", redacted.HealthCheck.Probes["8081"].Command)
+	assert.Equal(t, cfg.Server, redacted.Server)
+
+	// The original keeps its secrets
+	assert.Equal(t, "s3cr3t", cfg.HealthCheck.Probes["8081"].Env["TOKEN"])
+}
+
+func TestResolve_Secrets(t *testing.T) {
+	path := writeConfig(t, "app-config.yaml", `
+backend:
+  routes: [8081]
+health_check:
+  probes:
+    "8081":
+      type: exec
+      command: check
+      env:
+        TOKEN: env:PROBE_TOKEN
+`)
+
+	r, err := Resolve([]string{"-config", path}, []string{"PROBE_TOKEN=s3cr3t"})
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", r.Config.HealthCheck.Probes["8081"].Env["TOKEN"])
+
+	// Logged values are redacted
+	var buf bytes.Buffer
+	assert.NoError(t, r.Dump(&buf))
+	assert.NotContains(t, buf.String(), "s3cr3t")
+	assert.Contains(t, buf.String(), Redacted)
+
+	// Layers above the file can give references too
+	r, err = Resolve([]string{"-config", path, "-set", "health_check.probes.8081.env.TOKEN=env:OTHER_TOKEN"},
+		[]string{"OTHER_TOKEN=0th3r"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0th3r", r.Config.HealthCheck.Probes["8081"].Env["TOKEN"])
+
+	_, err = Resolve([]string{"-config", path}, nil)
+	assert.ErrorContains(t, err, "environment variable PROBE_TOKEN is not set")
+}
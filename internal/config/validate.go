@@ -0,0 +1,394 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// builtinPaths are the paths the Round Robin API serves ahead of its routing table, besides the healthcheck endpoint,
+// by what they serve. A path ending with '/' is served with every path below it.
+var builtinPaths = map[string]string{
+	"/metrics":              "the metrics endpoint",
+	"/livez":                "the liveness endpoint",
+	"/readyz":               "the readiness endpoint",
+	"/admin/health/history": "the health history endpoint",
+	"/route":                "the legacy /route endpoint",
+	"/route/":               "the legacy /route endpoint",
+}
+
+// reservedPaths are the other paths the Round Robin API and Application API servers serve, by what they serve.
+// The healthcheck endpoint is served on the same servers, so it can use neither these nor builtinPaths.
+var reservedPaths = map[string]string{
+	"/":        "the routing table",
+	"/mirror":  "the /mirror endpoint of the Application API",
+	"/mirror/": "the /mirror endpoint of the Application API",
+}
+
+// reservedPath returns what already serves path when the healthcheck endpoint cannot use it.
+func reservedPath(path string) (string, bool) {
+	if owner, ok := builtinPaths[path]; ok {
+		return owner, true
+	}
+	owner, ok := reservedPaths[path]
+	return owner, ok
+}
+
+// builtinPrefix returns what serves the requests a routing rule with the path prefix is meant for, ahead of the
+// routing table: the prefix is a built-in path or the healthcheck endpoint, or lies below a built-in path ending
+// with '/'.
+func (c *Config) builtinPrefix(prefix string) (string, bool) {
+	paths := map[string]string{c.Backend.Endpoint[HealthcheckEndpoint].URL: "the healthcheck endpoint"}
+	for path, owner := range builtinPaths {
+		paths[path] = owner
+	}
+	for _, path := range sortedKeys(paths) {
+		if path == "/" {
+			continue // A healthcheck endpoint at "/" is reported on its own
+		}
+		if path == prefix || strings.HasSuffix(path, "/") && strings.HasPrefix(prefix, path) {
+			return paths[path], true
+		}
+	}
+	return "", false
+}
+
+// Problem is a single invalid value in a configuration.
+type Problem struct {
+	Path    string // Dotted field path of the value, e.g. "backend.routes.1"
+	Message string
+}
+
+// ValidationError lists every problem found in a configuration.
+type ValidationError struct {
+	Problems []Problem
+}
+
+// Error lists the problems one per line, each prefixed with its field path.
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid config: %d problem(s)", len(e.Problems))
+	for _, p := range e.Problems {
+		fmt.Fprintf(&b, "\n  %s: %s", p.Path, p.Message)
+	}
+	return b.String()
+}
+
+// validator collects the problems found while checking a configuration.
+type validator struct {
+	problems []Problem
+}
+
+// addf records a problem with the value at path.
+func (v *validator) addf(path, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// nonNegative records a problem when value is below 0.
+func (v *validator) nonNegative(path string, value int64) {
+	if value < 0 {
+		v.addf(path, "must not be negative, got %d", value)
+	}
+}
+
+// nonNegativeDuration records a problem when d is below 0.
+func (v *validator) nonNegativeDuration(path string, d Duration) {
+	if d < 0 {
+		v.addf(path, "must not be negative, got %s", d)
+	}
+}
+
+// between records a problem when value is outside [low, high].
+func (v *validator) between(path string, value, low, high int) {
+	if value < low || value > high {
+		v.addf(path, "must be between %d and %d, got %d", low, high, value)
+	}
+}
+
+// Validate checks the configuration for values the servers cannot run with.
+// It reports every problem at once as a *ValidationError, or returns nil when the configuration is valid.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	c.validateServer(v)
+	c.validateBackend(v)
+	c.validatePools(v)
+	c.validateRouting(v)
+	c.validateHealthCheck(v)
+
+	if c.HealthCheckInterval <= 0 {
+		v.addf("healthCheck_ticker_time_seconds", "must be positive, got %s", c.HealthCheckInterval)
+	}
+	v.nonNegativeDuration("graceful_timeout_seconds", c.GracefulTimeout)
+	v.nonNegativeDuration("reload.watch_interval_seconds", c.Reload.WatchInterval)
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+func (c *Config) validateServer(v *validator) {
+	if err := checkPort(c.Server.Port); err != nil {
+		v.addf("server.port", "%v", err)
+	}
+	v.nonNegativeDuration("server.timeout", c.Server.Timeout)
+	validatePolicy(v, "server.policy", c.Server.Policy)
+	for i, entry := range c.Server.TrustedProxies {
+		if _, err := ParseNetwork(entry); err != nil {
+			v.addf("server.trusted_proxies."+strconv.Itoa(i), "%v", err)
+		}
+	}
+}
+
+func (c *Config) validateBackend(v *validator) {
+	if len(c.Backend.Routes) == 0 && len(c.Pools) == 0 {
+		v.addf("backend.routes", "at least one backend route or pool is required")
+	}
+	c.validateRoutes(v, "backend.routes", c.Backend.Routes)
+
+	if endpoint, ok := c.Backend.Endpoint[HealthcheckEndpoint]; !ok {
+		v.addf("backend.endpoints", "the %q endpoint is required", HealthcheckEndpoint)
+	} else if owner, ok := reservedPath(endpoint.URL); ok {
+		v.addf("backend.endpoints."+HealthcheckEndpoint+".url", "%q is reserved for %s", endpoint.URL, owner)
+	}
+	for _, name := range sortedKeys(c.Backend.Endpoint) {
+		endpoint, path := c.Backend.Endpoint[name], "backend.endpoints."+name
+		if !strings.HasPrefix(endpoint.URL, "/") {
+			v.addf(path+".url", "must be a path starting with '/', got %q", endpoint.URL)
+		}
+		v.nonNegativeDuration(path+".timeout", endpoint.Timeout)
+	}
+
+	names := make(map[string]string)
+	for i, check := range c.Backend.Checks {
+		path := "backend.checks." + strconv.Itoa(i)
+		if check.Name == "" {
+			v.addf(path+".name", "is required")
+		} else if first, ok := names[check.Name]; ok {
+			v.addf(path+".name", "%q duplicates %s", check.Name, first)
+		} else {
+			names[check.Name] = path
+		}
+
+		switch check.Type {
+		case "disk":
+			if check.Path == "" {
+				v.addf(path+".path", "is required for disk checks")
+			}
+		case "http":
+			if err := checkURL(check.URL); err != nil {
+				v.addf(path+".url", "%v", err)
+			}
+		default:
+			v.addf(path+".type", "must be \"disk\" or \"http\", got %q", check.Type)
+		}
+		v.nonNegativeDuration(path+".timeout", check.Timeout)
+	}
+}
+
+// validateRoutes checks a list of backends: every backend must be a valid port or URL and appear only once.
+func (c *Config) validateRoutes(v *validator, path string, routes []Route) {
+	seen := map[string]string{c.Server.Port: "server.port"}
+	for i, route := range routes {
+		path := path + "." + strconv.Itoa(i)
+		if err := checkRoute(route); err != nil {
+			v.addf(path, "%v", err)
+			continue
+		}
+		if route.Weight < 0 {
+			v.addf(path+".weight", "must not be negative, got %d", route.Weight)
+		}
+
+		// Bare ports are served locally, so they must not clash with each other or with the Round Robin API
+		key := route.BaseURL()
+		if port, ok := route.Port(); ok {
+			key = port
+		}
+		if first, ok := seen[key]; ok {
+			v.addf(path, "%q duplicates %s", route.URL, first)
+			continue
+		}
+		seen[key] = path
+	}
+}
+
+func (c *Config) validatePools(v *validator) {
+	if _, ok := c.Pools[DefaultPool]; ok && len(c.Backend.Routes) > 0 {
+		v.addf("pools."+DefaultPool, "conflicts with the %q pool made of backend.routes", DefaultPool)
+	}
+
+	for _, name := range sortedKeys(c.Pools) {
+		pool, path := c.Pools[name], "pools."+name
+		if len(pool.Backends) == 0 {
+			v.addf(path+".backends", "at least one backend is required")
+		}
+		c.validateRoutes(v, path+".backends", pool.Backends)
+
+		switch pool.Strategy {
+		case "", StrategyRoundRobin, StrategyRandom:
+		default:
+			v.addf(path+".strategy", "must be %q or %q, got %q", StrategyRoundRobin, StrategyRandom, pool.Strategy)
+		}
+		if pool.HealthCheck.URL != "" && !strings.HasPrefix(pool.HealthCheck.URL, "/") {
+			v.addf(path+".health_check.url", "must be a path starting with '/', got %q", pool.HealthCheck.URL)
+		}
+		v.nonNegativeDuration(path+".health_check.timeout", pool.HealthCheck.Timeout)
+		validatePolicy(v, path+".policy", pool.Policy)
+	}
+
+	// A backend shared by several pools is health checked once, so they must agree on how
+	pools := c.BackendPools()
+	healthChecks := make(map[string]Endpoint)
+	firstPool := make(map[string]string)
+	for _, name := range c.PoolNames() {
+		healthCheck := pools[name].HealthCheck
+		for _, route := range pools[name].Backends {
+			first, ok := firstPool[route.Name()]
+			if !ok {
+				firstPool[route.Name()] = name
+				healthChecks[route.Name()] = healthCheck
+				continue
+			}
+			if firstCheck := healthChecks[route.Name()]; firstCheck.URL != healthCheck.URL {
+				v.addf("pools."+name+".health_check.url", "backend %q is also in pool %q with health check %q",
+					route.Name(), first, firstCheck.URL)
+			} else if firstCheck.Timeout != healthCheck.Timeout {
+				v.addf("pools."+name+".health_check.timeout", "backend %q is also in pool %q with health check timeout %s",
+					route.Name(), first, firstCheck.Timeout)
+			}
+		}
+	}
+}
+
+func (c *Config) validateRouting(v *validator) {
+	pools := c.BackendPools()
+	for i, rule := range c.Routing.Rules {
+		path := "routing.rules." + strconv.Itoa(i)
+		if _, ok := pools[rule.Pool]; !ok {
+			v.addf(path+".pool", "does not name a pool, got %q", rule.Pool)
+		}
+		if prefix := rule.Match.PathPrefix; prefix != "" {
+			if !strings.HasPrefix(prefix, "/") {
+				v.addf(path+".match.path_prefix", "must start with '/', got %q", prefix)
+			} else if owner, ok := c.builtinPrefix(prefix); ok {
+				v.addf(path+".match.path_prefix", "%q is served by %s ahead of the routing table", prefix, owner)
+			}
+		}
+		if rule.StripPrefix != "" && !strings.HasPrefix(rule.StripPrefix, "/") {
+			v.addf(path+".strip_prefix", "must start with '/', got %q", rule.StripPrefix)
+		}
+		if rule.AddPrefix != "" && !strings.HasPrefix(rule.AddPrefix, "/") {
+			v.addf(path+".add_prefix", "must start with '/', got %q", rule.AddPrefix)
+		}
+		if _, err := regexp.Compile(rule.Match.PathRegex); err != nil {
+			v.addf(path+".match.path_regex", "%v", err)
+		}
+		for j, method := range rule.Match.Methods {
+			if method == "" || strings.ToUpper(method) != method || strings.ContainsAny(method, " \t/") {
+				v.addf(path+".match.methods."+strconv.Itoa(j), "must be an upper-case method name, got %q", method)
+			}
+		}
+		validatePolicy(v, path+".policy", rule.Policy)
+		if rule.Policy.Retry.BudgetPercent != 0 {
+			v.addf(path+".policy.retry.budget_percent", "can only be set in server.policy or the policy of a pool")
+		}
+	}
+	if c.Routing.Default != "" {
+		if _, ok := pools[c.Routing.Default]; !ok {
+			v.addf("routing.default", "does not name a pool, got %q", c.Routing.Default)
+		}
+	}
+}
+
+// validatePolicy checks the limits of a forwarding policy.
+func validatePolicy(v *validator, path string, policy Policy) {
+	v.nonNegativeDuration(path+".timeout", policy.Timeout)
+	v.nonNegativeDuration(path+".connect_timeout", policy.ConnectTimeout)
+	v.nonNegativeDuration(path+".response_header_timeout", policy.ResponseHeaderTimeout)
+	v.nonNegative(path+".max_body_bytes", policy.MaxBodyBytes)
+	v.nonNegative(path+".retry.attempts", int64(policy.Retry.Attempts))
+	for i, status := range policy.Retry.Statuses {
+		v.between(path+".retry.statuses."+strconv.Itoa(i), status, 100, 599)
+	}
+	v.nonNegativeDuration(path+".retry.backoff", policy.Retry.Backoff)
+	v.nonNegativeDuration(path+".retry.max_backoff", policy.Retry.MaxBackoff)
+	v.between(path+".retry.budget_percent", policy.Retry.BudgetPercent, 0, 100)
+}
+
+func (c *Config) validateHealthCheck(v *validator) {
+	hc := c.HealthCheck
+	v.nonNegative("health_check.workers", int64(hc.Workers))
+	v.nonNegativeDuration("health_check.unhealthy_interval_seconds", hc.UnhealthyInterval)
+	v.between("health_check.jitter_percent", hc.JitterPercent, 0, MaxJitterPercent)
+	v.between("health_check.panic_threshold_percent", hc.PanicThresholdPercent, 0, 100)
+	v.nonNegative("health_check.history_size", int64(hc.HistorySize))
+
+	routes := make(map[string]bool)
+	for _, pool := range c.BackendPools() {
+		for _, route := range pool.Backends {
+			routes[route.Name()] = true
+		}
+	}
+	if len(routes) > 0 {
+		v.between("health_check.min_healthy_backends", hc.MinHealthyBackends, 0, len(routes))
+	}
+	for _, name := range sortedKeys(hc.Probes) {
+		probe, path := hc.Probes[name], "health_check.probes."+name
+		if !routes[name] {
+			v.addf(path, "does not name a backend route")
+		}
+		switch probe.Type {
+		case "", "http":
+		case "exec":
+			if probe.Command == "" {
+				v.addf(path+".command", "is required for exec probes")
+			}
+		default:
+			v.addf(path+".type", "must be \"http\" or \"exec\", got %q", probe.Type)
+		}
+		v.nonNegativeDuration(path+".timeout", probe.Timeout)
+	}
+}
+
+// checkPort reports an error unless s is a TCP port between 1 and 65535.
+func checkPort(s string) error {
+	port, err := strconv.Atoi(s)
+	if err != nil || !isPort(s) || port < 1 || port > 65535 {
+		return fmt.Errorf("must be a port between 1 and 65535, got %q", s)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m in order, so problems are reported in a stable order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkRoute reports an error unless the route is a valid port or backend URL.
+func checkRoute(route Route) error {
+	if isPort(route.URL) {
+		return checkPort(route.URL)
+	}
+	return checkURL(route.URL)
+}
+
+// checkURL reports an error unless s is an absolute http or https URL.
+func checkURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http(s) URL with a host, got %q", s)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("must not have a query or fragment, got %q", s)
+	}
+	return nil
+}
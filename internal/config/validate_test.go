@@ -0,0 +1,294 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// validConfig returns a configuration that passes validation.
+func validConfig() *Config {
+	cfg := Defaults()
+	cfg.Backend.Routes = Routes("8081", "8082", "https://api.internal:8443/v1")
+	cfg.Backend.Checks = []DependencyCheck{{Name: "disk", Type: "disk", Path: "/", Timeout: Seconds(2), Critical: true}}
+	cfg.HealthCheck.Probes = map[string]Probe{"8082": {Type: "exec", Command: "/bin/true"}}
+	return cfg
+}
+
+func TestValidate_Valid(t *testing.T) {
+	assert.NoError(t, validConfig().Validate())
+}
+
+func TestValidate_ReservedHealthcheckURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{url: "/", expected: `"/" is reserved for the routing table`},
+		{url: "/metrics", expected: `"/metrics" is reserved for the metrics endpoint`},
+		{url: "/livez", expected: `"/livez" is reserved for the liveness endpoint`},
+		{url: "/readyz", expected: `"/readyz" is reserved for the readiness endpoint`},
+		{url: "/admin/health/history", expected: `"/admin/health/history" is reserved for the health history endpoint`},
+		{url: "/route", expected: `"/route" is reserved for the legacy /route endpoint`},
+		{url: "/route/", expected: `"/route/" is reserved for the legacy /route endpoint`},
+		{url: "/mirror/", expected: `"/mirror/" is reserved for the /mirror endpoint of the Application API`},
+		{url: "/health"},
+		{url: "/metrics/health"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Backend.Endpoint[HealthcheckEndpoint] = Endpoint{URL: tt.url, Timeout: Seconds(2)}
+
+			err := cfg.Validate()
+			if tt.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr *ValidationError
+			if assert.ErrorAs(t, err, &validationErr) {
+				assert.Equal(t, []Problem{{Path: "backend.endpoints.healthcheck.url", Message: tt.expected}}, validationErr.Problems)
+			}
+		})
+	}
+}
+
+func TestValidate_Problems(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(cfg *Config)
+		expected []Problem
+	}{
+		{
+			name:   "ZeroTicker",
+			modify: func(cfg *Config) { cfg.HealthCheckInterval = 0 },
+			expected: []Problem{
+				{Path: "healthCheck_ticker_time_seconds", Message: "must be positive, got 0s"},
+			},
+		},
+		{
+			name:   "MissingHealthcheckEndpoint",
+			modify: func(cfg *Config) { delete(cfg.Backend.Endpoint, HealthcheckEndpoint) },
+			expected: []Problem{
+				{Path: "backend.endpoints", Message: `the "healthcheck" endpoint is required`},
+			},
+		},
+		{
+			name: "InvalidEndpoint",
+			modify: func(cfg *Config) {
+				cfg.Backend.Endpoint["status"] = Endpoint{URL: "status", Timeout: Seconds(-1)}
+			},
+			expected: []Problem{
+				{Path: "backend.endpoints.status.url", Message: `must be a path starting with '/', got "status"`},
+				{Path: "backend.endpoints.status.timeout", Message: "must not be negative, got -1s"},
+			},
+		},
+		{
+			name: "InvalidPorts",
+			modify: func(cfg *Config) {
+				cfg.Server.Port = "http"
+				cfg.Backend.Routes = Routes("8081", "70000", "8081", "localhost:8083")
+				cfg.HealthCheck.Probes = nil
+			},
+			expected: []Problem{
+				{Path: "server.port", Message: `must be a port between 1 and 65535, got "http"`},
+				{Path: "backend.routes.1", Message: `must be a port between 1 and 65535, got "70000"`},
+				{Path: "backend.routes.2", Message: `"8081" duplicates backend.routes.0`},
+				{Path: "backend.routes.3", Message: `must be an http(s) URL with a host, got "localhost:8083"`},
+			},
+		},
+		{
+			name: "DuplicateURLsAndServerPort",
+			modify: func(cfg *Config) {
+				cfg.Backend.Routes = []Route{{URL: "8080"}, {URL: "https://a.internal/"}, {URL: "https://a.internal", Weight: -2}}
+				cfg.HealthCheck.Probes = nil
+			},
+			expected: []Problem{
+				{Path: "backend.routes.0", Message: `"8080" duplicates server.port`},
+				{Path: "backend.routes.2.weight", Message: "must not be negative, got -2"},
+				{Path: "backend.routes.2", Message: `"https://a.internal" duplicates backend.routes.1`},
+			},
+		},
+		{
+			name: "NoRoutes",
+			modify: func(cfg *Config) {
+				cfg.Backend.Routes = nil
+				cfg.HealthCheck.Probes = nil
+			},
+			expected: []Problem{
+				{Path: "backend.routes", Message: "at least one backend route or pool is required"},
+			},
+		},
+		{
+			name: "InvalidChecks",
+			modify: func(cfg *Config) {
+				cfg.Backend.Checks = []DependencyCheck{
+					{Name: "disk", Type: "disk"},
+					{Name: "disk", Type: "http", URL: "/ping"},
+					{Type: "tcp", Timeout: Seconds(-1)},
+				}
+			},
+			expected: []Problem{
+				{Path: "backend.checks.0.path", Message: "is required for disk checks"},
+				{Path: "backend.checks.1.name", Message: `"disk" duplicates backend.checks.0`},
+				{Path: "backend.checks.1.url", Message: `must be an http(s) URL with a host, got "/ping"`},
+				{Path: "backend.checks.2.name", Message: "is required"},
+				{Path: "backend.checks.2.type", Message: `must be "disk" or "http", got "tcp"`},
+				{Path: "backend.checks.2.timeout", Message: "must not be negative, got -1s"},
+			},
+		},
+		{
+			name: "InvalidHealthCheck",
+			modify: func(cfg *Config) {
+				cfg.HealthCheck.Workers = -1
+				cfg.HealthCheck.JitterPercent = 80
+				cfg.HealthCheck.PanicThresholdPercent = 101
+				cfg.HealthCheck.MinHealthyBackends = 4
+				cfg.HealthCheck.Probes = map[string]Probe{
+					"8082": {Type: "exec"},
+					"9090": {Type: "tcp"},
+				}
+			},
+			expected: []Problem{
+				{Path: "health_check.workers", Message: "must not be negative, got -1"},
+				{Path: "health_check.jitter_percent", Message: "must be between 0 and 50, got 80"},
+				{Path: "health_check.panic_threshold_percent", Message: "must be between 0 and 100, got 101"},
+				{Path: "health_check.min_healthy_backends", Message: "must be between 0 and 3, got 4"},
+				{Path: "health_check.probes.8082.command", Message: "is required for exec probes"},
+				{Path: "health_check.probes.9090", Message: "does not name a backend route"},
+				{Path: "health_check.probes.9090.type", Message: `must be "http" or "exec", got "tcp"`},
+			},
+		},
+		{
+			name: "InvalidPools",
+			modify: func(cfg *Config) {
+				cfg.Pools = map[string]Pool{
+					"default": {Backends: Routes("9091")},
+					"api": {
+						Backends:    []Route{{URL: "8081"}, {URL: "https://api.internal"}, {URL: "https://api.internal/"}},
+						Strategy:    "least_conn",
+						HealthCheck: Endpoint{URL: "/status", Timeout: Seconds(-1)},
+					},
+					"empty": {Policy: Policy{Timeout: Seconds(-5)}},
+				}
+			},
+			expected: []Problem{
+				{Path: "pools.default", Message: `conflicts with the "default" pool made of backend.routes`},
+				{Path: "pools.api.backends.2", Message: `"https://api.internal/" duplicates pools.api.backends.1`},
+				{Path: "pools.api.strategy", Message: `must be "round_robin" or "random", got "least_conn"`},
+				{Path: "pools.api.health_check.timeout", Message: "must not be negative, got -1s"},
+				{Path: "pools.empty.backends", Message: "at least one backend is required"},
+				{Path: "pools.empty.policy.timeout", Message: "must not be negative, got -5s"},
+				{Path: "pools.api.health_check.url", Message: `backend "8081" is also in pool "default" with health check "/health"`},
+			},
+		},
+		{
+			name: "SharedBackendHealthCheckTimeout",
+			modify: func(cfg *Config) {
+				cfg.Pools = map[string]Pool{
+					"api":    {Backends: Routes("8081"), HealthCheck: Endpoint{Timeout: Seconds(5)}},
+					"static": {Backends: Routes("8082"), HealthCheck: Endpoint{URL: "/health", Timeout: Seconds(2)}},
+				}
+			},
+			expected: []Problem{
+				{Path: "pools.api.health_check.timeout", Message: `backend "8081" is also in pool "default" with health check timeout 2s`},
+			},
+		},
+		{
+			name: "InvalidRouting",
+			modify: func(cfg *Config) {
+				cfg.Pools = map[string]Pool{"api": {Backends: Routes("https://api.internal")}}
+				cfg.Routing = Routing{
+					Rules: []RoutingRule{
+						{Name: "api", Match: Match{PathPrefix: "/api", Methods: []string{"GET"}}, Pool: "api"},
+						{Name: "broken", Match: Match{PathPrefix: "v2", PathRegex: "([", Methods: []string{"get"}}, Pool: "web", StripPrefix: "v2", AddPrefix: "v3/"},
+					},
+					Default: "missing",
+				}
+			},
+			expected: []Problem{
+				{Path: "routing.rules.1.pool", Message: `does not name a pool, got "web"`},
+				{Path: "routing.rules.1.match.path_prefix", Message: `must start with '/', got "v2"`},
+				{Path: "routing.rules.1.strip_prefix", Message: `must start with '/', got "v2"`},
+				{Path: "routing.rules.1.add_prefix", Message: `must start with '/', got "v3/"`},
+				{Path: "routing.rules.1.match.path_regex", Message: "error parsing regexp: missing closing ]: `[`"},
+				{Path: "routing.rules.1.match.methods.0", Message: `must be an upper-case method name, got "get"`},
+				{Path: "routing.default", Message: `does not name a pool, got "missing"`},
+			},
+		},
+		{
+			name: "BuiltinRoutingPrefixes",
+			modify: func(cfg *Config) {
+				cfg.Routing.Rules = []RoutingRule{
+					{Match: Match{PathPrefix: "/metrics"}, Pool: DefaultPool},
+					{Match: Match{PathPrefix: "/route/users"}, Pool: DefaultPool},
+					{Match: Match{PathPrefix: "/route/"}, Pool: DefaultPool},
+					{Match: Match{PathPrefix: "/health"}, Pool: DefaultPool},
+					{Match: Match{PathPrefix: "/metrics/"}, Pool: DefaultPool},
+					{Match: Match{PathPrefix: "/metricsv2"}, Pool: DefaultPool},
+					{Match: Match{PathPrefix: "/routes"}, Pool: DefaultPool},
+					{Match: Match{PathPrefix: "/"}, Pool: DefaultPool},
+				}
+			},
+			expected: []Problem{
+				{Path: "routing.rules.0.match.path_prefix", Message: `"/metrics" is served by the metrics endpoint ahead of the routing table`},
+				{Path: "routing.rules.1.match.path_prefix", Message: `"/route/users" is served by the legacy /route endpoint ahead of the routing table`},
+				{Path: "routing.rules.2.match.path_prefix", Message: `"/route/" is served by the legacy /route endpoint ahead of the routing table`},
+				{Path: "routing.rules.3.match.path_prefix", Message: `"/health" is served by the healthcheck endpoint ahead of the routing table`},
+			},
+		},
+		{
+			name: "InvalidPolicies",
+			modify: func(cfg *Config) {
+				cfg.Server.Policy = Policy{ConnectTimeout: Seconds(-1), Retry: Retry{Attempts: -2, Backoff: Seconds(-1), BudgetPercent: 120}}
+				cfg.Routing.Rules = []RoutingRule{{
+					Pool: DefaultPool,
+					Policy: Policy{ResponseHeaderTimeout: Seconds(-3), MaxBodyBytes: -4,
+						Retry: Retry{Statuses: []int{503, 42}, MaxBackoff: Seconds(-5), BudgetPercent: 10}},
+				}}
+			},
+			expected: []Problem{
+				{Path: "server.policy.connect_timeout", Message: "must not be negative, got -1s"},
+				{Path: "server.policy.retry.attempts", Message: "must not be negative, got -2"},
+				{Path: "server.policy.retry.backoff", Message: "must not be negative, got -1s"},
+				{Path: "server.policy.retry.budget_percent", Message: "must be between 0 and 100, got 120"},
+				{Path: "routing.rules.0.policy.response_header_timeout", Message: "must not be negative, got -3s"},
+				{Path: "routing.rules.0.policy.max_body_bytes", Message: "must not be negative, got -4"},
+				{Path: "routing.rules.0.policy.retry.statuses.1", Message: "must be between 100 and 599, got 42"},
+				{Path: "routing.rules.0.policy.retry.max_backoff", Message: "must not be negative, got -5s"},
+				{Path: "routing.rules.0.policy.retry.budget_percent", Message: "can only be set in server.policy or the policy of a pool"},
+			},
+		},
+		{
+			name: "InvalidTrustedProxies",
+			modify: func(cfg *Config) {
+				cfg.Server.TrustedProxies = []string{"10.0.0.0/8", "proxy.internal", "::1", "10.0.0.1/33"}
+			},
+			expected: []Problem{
+				{Path: "server.trusted_proxies.1", Message: `must be an IP address or a CIDR network, got "proxy.internal"`},
+				{Path: "server.trusted_proxies.3", Message: `must be an IP address or a CIDR network, got "10.0.0.1/33"`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			var validationErr *ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.expected, validationErr.Problems)
+		})
+	}
+}
+
+func TestValidationError_Error(t *testing.T) {
+	err := &ValidationError{Problems: []Problem{
+		{Path: "server.port", Message: "must be a port"},
+		{Path: "backend.routes", Message: "at least one backend route is required"},
+	}}
+	assert.Equal(t, "invalid config: 2 problem(s)\n  server.port: must be a port\n  backend.routes: at least one backend route is required", err.Error())
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// replayBufferBytes is the most of a request body kept to send it again on a retry. Larger bodies are only retried
+// when none of them was sent yet.
+const replayBufferBytes = 1 << 20
+
+// errAttemptOver fails the reads of an attempt once another one took over the body.
+var errAttemptOver = errors.New("request body was handed over to another attempt")
+
+// requestBody is the body of an incoming request as it is streamed to backends. It remembers the error reading it
+// failed with, so a client that sent too much or stopped sending is told apart from a failing backend, and keeps the
+// start of the body, up to its replay limit, so a retry can send the body again from the start.
+type requestBody struct {
+	body io.ReadCloser
+
+	readMu sync.Mutex // Serializes reads of body: a failed attempt may still be reading on a goroutine of the client
+
+	mu       sync.Mutex // Guards the fields below
+	err      error
+	read     int64  // Bytes read from body
+	limit    int64  // Most bytes kept in replay
+	replay   []byte // Bytes read from body, while they fit in limit
+	overflow bool   // More than limit bytes were read, so replay is dropped
+	attempt  int    // Current attempt; the readers of the others fail
+}
+
+// newRequestBody returns the body of a request, keeping up to limit bytes of it to send it again.
+func newRequestBody(body io.ReadCloser, limit int64) *requestBody {
+	return &requestBody{body: body, limit: limit}
+}
+
+// Attempt returns the body to send in a new attempt: the bytes read so far, then the rest of the body.
+// The readers of earlier attempts fail from then on. Only call it when Replayable reports true.
+func (b *requestBody) Attempt() io.ReadCloser {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.attempt++
+	return &attemptBody{body: b, attempt: b.attempt}
+}
+
+// readAt reads the body for attempt a from its position: from the bytes kept while they last, then from the
+// incoming body, keeping what it reads for replays.
+func (b *requestBody) readAt(p []byte, a *attemptBody) (int, error) {
+	if n, ok, err := b.replayAt(p, a); ok {
+		return n, err
+	}
+
+	b.readMu.Lock()
+	defer b.readMu.Unlock()
+	// A read of an earlier attempt may have been in progress, and kept more bytes meanwhile
+	if n, ok, err := b.replayAt(p, a); ok {
+		return n, err
+	}
+
+	n, err := b.body.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.read += int64(n)
+	if !b.overflow {
+		if b.read > b.limit {
+			b.overflow, b.replay = true, nil
+		} else {
+			b.replay = append(b.replay, p[:n]...)
+		}
+	}
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	if a.attempt != b.attempt {
+		return 0, errAttemptOver
+	}
+	a.pos += int64(n)
+	return n, err
+}
+
+// replayAt serves a read of attempt a from the bytes kept, and reports false when a must read on from the incoming body.
+func (b *requestBody) replayAt(p []byte, a *attemptBody) (int, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case a.attempt != b.attempt:
+		return 0, true, errAttemptOver
+	case a.pos == b.read:
+		return 0, false, nil
+	case b.overflow:
+		return 0, true, errAttemptOver // The bytes before a.pos are gone
+	}
+	n := copy(p, b.replay[a.pos:])
+	a.pos += int64(n)
+	return n, true, nil
+}
+
+// Err returns the error reading the body failed with, or nil.
+func (b *requestBody) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// Untouched reports whether nothing of the body was read yet.
+func (b *requestBody) Untouched() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.read == 0 && b.err == nil
+}
+
+// Replayable reports whether the body can be sent again from the start: everything read of it was kept.
+func (b *requestBody) Replayable() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.overflow && b.err == nil
+}
+
+// attemptBody is the body of a request as sent in one attempt: the replayed start of the body, then the rest of it.
+type attemptBody struct {
+	body    *requestBody
+	attempt int
+	pos     int64 // Bytes of the body sent in this attempt
+}
+
+func (a *attemptBody) Read(p []byte) (int, error) {
+	return a.body.readAt(p, a)
+}
+
+// Close leaves the incoming body open: the client closes the body of a request that fails, which must not stop
+// it from being retried. The server closes the incoming body once the request is answered.
+func (a *attemptBody) Close() error {
+	return nil
+}
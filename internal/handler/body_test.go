@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+	"github.com/samargupta114/Roundrobinator.git/pkg/utils/httpclient"
+)
+
+// TestRoutingHandler_RequestBody tests the answers to requests whose body cannot be forwarded.
+func TestRoutingHandler_RequestBody(t *testing.T) {
+	tests := []struct {
+		name               string
+		body               io.Reader
+		contentLength      int64
+		policy             config.Policy
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "Body forwarded",
+			body:               strings.NewReader("payload"),
+			contentLength:      7,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "OK",
+		},
+		{
+			name:               "Body read error",
+			body:               &errorReader{},
+			contentLength:      -1,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "Error reading request body\n",
+		},
+		{
+			name:               "Body of unknown length over the limit",
+			body:               strings.NewReader("0123456789"),
+			contentLength:      -1,
+			policy:             config.Policy{MaxBodyBytes: 4},
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+			expectedBody:       "Request body too large\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &sequenceClient{responses: []*http.Response{response(http.StatusOK, "OK")}}
+			handler := RoutingHandler(func(r *http.Request) (Pool, bool) {
+				return Pool{Balancer: &MockRoundRobin{ports: []string{"8081"}}, Client: client, Policy: tt.policy}, true
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/upload", tt.body)
+			req.ContentLength = tt.contentLength
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+
+			if rr.Code != tt.expectedStatusCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatusCode, rr.Code)
+			}
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+// TestRoutingHandler_Streaming tests that request bodies reach the backend as they are sent, and only when it wants them.
+func TestRoutingHandler_Streaming(t *testing.T) {
+	firstChunk := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/stream":
+			buf := make([]byte, len("first "))
+			if _, err := io.ReadFull(r.Body, buf); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			close(firstChunk)
+			rest, _ := io.ReadAll(r.Body)
+			_, _ = w.Write(append(buf, rest...))
+		case "/unauthorized":
+			http.Error(w, "Unauthorized", http.StatusUnauthorized) // Without reading the body
+		default:
+			body, _ := io.ReadAll(r.Body)
+			_, _ = w.Write(body)
+		}
+	}))
+	defer backend.Close()
+
+	proxy := httptest.NewServer(RoutingHandler(func(r *http.Request) (Pool, bool) {
+		return Pool{
+			Balancer: &MockRoundRobin{ports: []string{backend.URL}},
+			Client:   httpclient.New(httpclient.Options{}),
+			Policy:   config.Policy{MaxBodyBytes: 1024, Retry: config.Retry{Attempts: 3}},
+		}, true
+	}))
+	defer proxy.Close()
+
+	t.Run("Body streamed", func(t *testing.T) {
+		pr, pw := io.Pipe()
+		go func() {
+			_, _ = io.WriteString(pw, "first ")
+			// The rest is only sent once the backend got the start: a buffered body would never get there
+			select {
+			case <-firstChunk:
+				_, _ = io.WriteString(pw, "second")
+				pw.Close()
+			case <-time.After(5 * time.Second):
+				pw.CloseWithError(io.ErrUnexpectedEOF)
+			}
+		}()
+
+		resp, err := http.Post(proxy.URL+"/stream", "text/plain", pr)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != "first second" {
+			t.Errorf("Expected status 200 and body %q, got %d and %q", "first second", resp.StatusCode, body)
+		}
+	})
+
+	// Clients waiting for 100 Continue only send their body once the backend asks for it, retries or not
+	client := &http.Client{Transport: &http.Transport{ExpectContinueTimeout: 5 * time.Second}}
+	expectContinue := func(path, body string) (*http.Response, *countingReader) {
+		reader := &countingReader{r: strings.NewReader(body)}
+		req, _ := http.NewRequest(http.MethodPut, proxy.URL+path, reader)
+		req.ContentLength = int64(len(body))
+		req.Header.Set("Expect", "100-continue")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return resp, reader
+	}
+
+	t.Run("Expect 100-continue accepted", func(t *testing.T) {
+		resp, reader := expectContinue("/echo", "payload")
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != "payload" {
+			t.Errorf("Expected status 200 and body %q, got %d and %q", "payload", resp.StatusCode, body)
+		}
+		if reader.n.Load() == 0 {
+			t.Errorf("Expected the body to be sent")
+		}
+	})
+
+	t.Run("Expect 100-continue rejected", func(t *testing.T) {
+		resp, reader := expectContinue("/unauthorized", "payload")
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", resp.StatusCode)
+		}
+		if n := reader.n.Load(); n != 0 {
+			t.Errorf("Expected the body not to be sent, %d bytes were", n)
+		}
+	})
+
+	t.Run("Expect 100-continue over the limit", func(t *testing.T) {
+		resp, reader := expectContinue("/echo", strings.Repeat("x", 2048))
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status 413, got %d", resp.StatusCode)
+		}
+		if n := reader.n.Load(); n != 0 {
+			t.Errorf("Expected the body not to be sent, %d bytes were", n)
+		}
+	})
+
+	t.Run("Chunked body over the limit", func(t *testing.T) {
+		resp, err := http.Post(proxy.URL+"/echo", "text/plain", io.MultiReader(strings.NewReader(strings.Repeat("x", 2048))))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status 413, got %d", resp.StatusCode)
+		}
+	})
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func TestRequestBody_Replay(t *testing.T) {
+	body := newRequestBody(io.NopCloser(strings.NewReader("0123456789")), 8)
+
+	first := body.Attempt()
+	buf := make([]byte, 4)
+	if n, _ := io.ReadFull(first, buf); n != 4 || string(buf) != "0123" {
+		t.Fatalf("Expected to read %q, got %q", "0123", buf[:n])
+	}
+	if body.Untouched() || !body.Replayable() {
+		t.Errorf("Expected a partly read body within the limit to be replayable")
+	}
+
+	// A new attempt sends the body from the start, and the earlier one can no longer read
+	second := body.Attempt()
+	if _, err := first.Read(buf); err != errAttemptOver {
+		t.Errorf("Expected the earlier attempt to fail with %v, got %v", errAttemptOver, err)
+	}
+	data, err := io.ReadAll(second)
+	if err != nil || string(data) != "0123456789" {
+		t.Errorf("Expected the whole body, got %q and %v", data, err)
+	}
+
+	// Past the limit, the start of the body is gone
+	if body.Replayable() {
+		t.Errorf("Expected a body over the limit not to be replayable")
+	}
+	if _, err := body.Attempt().Read(buf); err != errAttemptOver {
+		t.Errorf("Expected a replay past the limit to fail with %v, got %v", errAttemptOver, err)
+	}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Headers identifying the client of a proxied request to the backends.
+const (
+	headerForwarded       = "Forwarded"
+	headerXForwardedFor   = "X-Forwarded-For"
+	headerXForwardedHost  = "X-Forwarded-Host"
+	headerXForwardedProto = "X-Forwarded-Proto"
+)
+
+// setForwardedHeaders adds the client address, host and scheme of r to the X-Forwarded-* headers and to the
+// Forwarded header (RFC 7239) of h, the headers forwarded to the backend. The forwarded headers r came with
+// are kept and appended to when its peer is one of the trusted proxies, and replaced otherwise.
+func setForwardedHeaders(h http.Header, r *http.Request, trusted []netip.Prefix) {
+	client, clientOK := remoteAddr(r)
+	proto := "http"
+	if r.TLS != nil {
+		proto = "https"
+	}
+
+	if !clientOK || !trusts(trusted, client) {
+		for _, name := range []string{headerForwarded, headerXForwardedFor, headerXForwardedHost, headerXForwardedProto} {
+			h.Del(name)
+		}
+	}
+
+	// The host and scheme are those the first proxy saw, so they are only set when no trusted proxy did
+	if h.Get(headerXForwardedHost) == "" && r.Host != "" {
+		h.Set(headerXForwardedHost, r.Host)
+	}
+	if h.Get(headerXForwardedProto) == "" {
+		h.Set(headerXForwardedProto, proto)
+	}
+
+	element := []string{"for=" + forwardedNode(client, clientOK)}
+	if r.Host != "" {
+		element = append(element, "host="+forwardedValue(r.Host))
+	}
+	element = append(element, "proto="+proto)
+	appendHeader(h, headerForwarded, strings.Join(element, ";"))
+
+	if clientOK {
+		appendHeader(h, headerXForwardedFor, client.String())
+	}
+}
+
+// appendHeader appends value to the comma-separated list of the header name, folding the values it has into one line.
+func appendHeader(h http.Header, name, value string) {
+	if existing := h.Values(name); len(existing) > 0 {
+		value = strings.Join(existing, ", ") + ", " + value
+	}
+	h.Set(name, value)
+}
+
+// remoteAddr returns the IP address of the peer r came from, or false when it is not known.
+func remoteAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// trusts reports whether addr belongs to one of the trusted networks.
+func trusts(trusted []netip.Prefix, addr netip.Addr) bool {
+	for _, network := range trusted {
+		if network.Contains(addr.WithZone("")) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedNode returns the for= value of a client address in a Forwarded header: IPv6 addresses are
+// bracketed and quoted, and an unknown address is "unknown".
+func forwardedNode(addr netip.Addr, ok bool) string {
+	switch {
+	case !ok:
+		return "unknown"
+	case addr.Is6():
+		return `"[` + addr.String() + `]"`
+	}
+	return addr.String()
+}
+
+// forwardedValue returns s as a Forwarded header value: as is when it is a token, quoted otherwise.
+func forwardedValue(s string) string {
+	for _, c := range s {
+		if !isTokenChar(c) {
+			return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+		}
+	}
+	return s
+}
+
+// isTokenChar reports whether c may appear in an HTTP token (RFC 7230, section 3.2.6).
+func isTokenChar(c rune) bool {
+	return c < 0x7f && c > 0x20 && !strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c)
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"crypto/tls"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+// headerClient is a mock client remembering the headers of the last forwarded request.
+type headerClient struct {
+	header http.Header
+}
+
+func (m *headerClient) ForwardRequest(r *http.Request, url string) (*http.Response, error) {
+	m.header = r.Header
+	return response(http.StatusOK, "OK"), nil
+}
+
+// TestRoutingHandler_ForwardedHeaders tests the forwarded and hop-by-hop headers of forwarded requests.
+func TestRoutingHandler_ForwardedHeaders(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8::/32")}
+
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		host           string
+		tls            bool
+		header         http.Header
+		trusted        []netip.Prefix
+		expectedHeader http.Header
+	}{
+		{
+			name:       "Direct client",
+			remoteAddr: "203.0.113.7:51000",
+			host:       "api.example.com",
+			header:     http.Header{"Accept": {"application/json"}},
+			expectedHeader: http.Header{
+				"Accept":            {"application/json"},
+				"X-Forwarded-For":   {"203.0.113.7"},
+				"X-Forwarded-Host":  {"api.example.com"},
+				"X-Forwarded-Proto": {"http"},
+				"Forwarded":         {"for=203.0.113.7;host=api.example.com;proto=http"},
+			},
+		},
+		{
+			name:       "Untrusted client headers are replaced",
+			remoteAddr: "203.0.113.7:51000",
+			host:       "api.example.com:8443",
+			tls:        true,
+			header: http.Header{
+				"X-Forwarded-For":   {"198.51.100.1"},
+				"X-Forwarded-Host":  {"evil.example.com"},
+				"X-Forwarded-Proto": {"http"},
+				"Forwarded":         {"for=198.51.100.1"},
+			},
+			trusted: trusted,
+			expectedHeader: http.Header{
+				"X-Forwarded-For":   {"203.0.113.7"},
+				"X-Forwarded-Host":  {"api.example.com:8443"},
+				"X-Forwarded-Proto": {"https"},
+				"Forwarded":         {`for=203.0.113.7;host="api.example.com:8443";proto=https`},
+			},
+		},
+		{
+			name:       "Trusted proxy headers are appended to",
+			remoteAddr: "10.1.2.3:40000",
+			host:       "api.internal",
+			header: http.Header{
+				"X-Forwarded-For":   {"198.51.100.1", "192.0.2.4"},
+				"X-Forwarded-Host":  {"api.example.com"},
+				"X-Forwarded-Proto": {"https"},
+				"Forwarded":         {"for=198.51.100.1;proto=https"},
+			},
+			trusted: trusted,
+			expectedHeader: http.Header{
+				"X-Forwarded-For":   {"198.51.100.1, 192.0.2.4, 10.1.2.3"},
+				"X-Forwarded-Host":  {"api.example.com"},
+				"X-Forwarded-Proto": {"https"},
+				"Forwarded":         {"for=198.51.100.1;proto=https, for=10.1.2.3;host=api.internal;proto=http"},
+			},
+		},
+		{
+			name:       "IPv6 client",
+			remoteAddr: "[2001:db8::1]:40000",
+			host:       "api.example.com",
+			trusted:    trusted,
+			expectedHeader: http.Header{
+				"X-Forwarded-For":   {"2001:db8::1"},
+				"X-Forwarded-Host":  {"api.example.com"},
+				"X-Forwarded-Proto": {"http"},
+				"Forwarded":         {`for="[2001:db8::1]";host=api.example.com;proto=http`},
+			},
+		},
+		{
+			name:       "Hop-by-hop headers are removed",
+			remoteAddr: "203.0.113.7:51000",
+			host:       "api.example.com",
+			header: http.Header{
+				"Connection":          {"keep-alive, X-Forwarded-For, X-Session"},
+				"Keep-Alive":          {"timeout=5"},
+				"Proxy-Authorization": {"Basic c2VjcmV0"},
+				"Te":                  {"trailers, deflate"},
+				"Upgrade":             {"websocket"},
+				"X-Session":           {"abc"},
+				"X-Request-Id":        {"42"},
+			},
+			expectedHeader: http.Header{
+				"Te":                {"trailers"},
+				"X-Request-Id":      {"42"},
+				"X-Forwarded-For":   {"203.0.113.7"},
+				"X-Forwarded-Host":  {"api.example.com"},
+				"X-Forwarded-Proto": {"http"},
+				"Forwarded":         {"for=203.0.113.7;host=api.example.com;proto=http"},
+			},
+		},
+		{
+			name:       "Unknown client address",
+			remoteAddr: "pipe",
+			host:       "api.example.com",
+			expectedHeader: http.Header{
+				"X-Forwarded-Host":  {"api.example.com"},
+				"X-Forwarded-Proto": {"http"},
+				"Forwarded":         {"for=unknown;host=api.example.com;proto=http"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &headerClient{}
+			handler := RoutingHandler(func(r *http.Request) (Pool, bool) {
+				return Pool{Balancer: &MockRoundRobin{ports: []string{"8081"}}, Client: client, TrustedProxies: tt.trusted}, true
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			req.RemoteAddr, req.Host = tt.remoteAddr, tt.host
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			incoming := req.Header.Clone()
+
+			handler(httptest.NewRecorder(), req)
+
+			if len(client.header) != len(tt.expectedHeader) {
+				t.Errorf("expected headers %v, got %v", tt.expectedHeader, client.header)
+			}
+			for key, values := range tt.expectedHeader {
+				if got := client.header.Values(key); !slices.Equal(got, values) {
+					t.Errorf("expected %s %q, got %q", key, values, got)
+				}
+			}
+			if !maps.EqualFunc(req.Header, incoming, slices.Equal) {
+				t.Errorf("expected the incoming headers to be left unchanged, got %v", req.Header)
+			}
+		})
+	}
+}
+
+// TestRoutingHandler_ResponseHopByHop tests that the hop-by-hop headers of backend responses are not sent back.
+func TestRoutingHandler_ResponseHopByHop(t *testing.T) {
+	resp := response(http.StatusOK, "OK")
+	resp.Header = http.Header{
+		"Connection":     {"X-Backend-Conn"},
+		"Keep-Alive":     {"timeout=5"},
+		"Upgrade":        {"h2c"},
+		"X-Backend-Conn": {"1"},
+		"Content-Type":   {"text/plain"},
+	}
+	handler := RoutingHandler(func(r *http.Request) (Pool, bool) {
+		return Pool{Balancer: &MockRoundRobin{ports: []string{"8081"}}, Client: &MockHttpClient{resp: resp}}, true
+	})
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	for _, key := range []string{"Connection", "Keep-Alive", "Upgrade", "X-Backend-Conn"} {
+		if got := rr.Header().Get(key); got != "" {
+			t.Errorf("expected no %s header, got %q", key, got)
+		}
+	}
+	if got := rr.Header().Get("Content-Type"); got != "text/plain" {
+		t.Errorf("expected Content-Type %q, got %q", "text/plain", got)
+	}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+	"github.com/samargupta114/Roundrobinator.git/internal/roundrobin"
+)
+
+// headerAttempts reports to the client how many backends a request was sent to, retries included.
+const headerAttempts = "X-Upstream-Attempts"
+
+const (
+	budgetWindow     = 10 * time.Second // Period over which the retries of a pool are compared to its requests
+	budgetMinRetries = 3                // Retries allowed every window whatever the traffic, so quiet pools retry too
+)
+
+// RetryBudget caps the retries of a pool at a percentage of its requests, counted over the current and the previous
+// budget window. It is safe for concurrent use, and the zero value is ready to use.
+type RetryBudget struct {
+	mu       sync.Mutex
+	start    time.Time        // Start of the current window
+	current  budgetCounts     // Counts of the current window
+	previous budgetCounts     // Counts of the window before it
+	now      func() time.Time // Clock, replaced in tests; nil is time.Now
+}
+
+// budgetCounts are the requests and retries of a pool over one budget window.
+type budgetCounts struct {
+	requests, retries int
+}
+
+// Request counts a request of the pool.
+func (b *RetryBudget) Request() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rotate()
+	b.current.requests++
+}
+
+// Retry reports whether the budget allows another retry when retries may be percent of the requests,
+// and counts it when it does. A percent of 0 allows every retry.
+func (b *RetryBudget) Retry(percent int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rotate()
+
+	requests := b.current.requests + b.previous.requests
+	retries := b.current.retries + b.previous.retries
+	if percent > 0 && retries >= max(budgetMinRetries, requests*percent/100) {
+		return false
+	}
+	b.current.retries++
+	return true
+}
+
+// rotate starts a new window when the current one is over. b.mu must be held.
+func (b *RetryBudget) rotate() {
+	now := time.Now()
+	if b.now != nil {
+		now = b.now()
+	}
+	switch elapsed := now.Sub(b.start); {
+	case elapsed >= 2*budgetWindow:
+		b.previous, b.current, b.start = budgetCounts{}, budgetCounts{}, now
+	case elapsed >= budgetWindow:
+		b.previous, b.current, b.start = b.current, budgetCounts{}, b.start.Add(budgetWindow)
+	}
+}
+
+// backoff returns how long to wait before the nth retry of the policy: a random duration up to the backoff doubled
+// for every retry before it, capped at the max backoff ("full jitter").
+func backoff(retry config.Retry, n int) time.Duration {
+	ceiling := retry.Backoff.Duration()
+	for i := 1; i < n && ceiling > 0 && ceiling < time.Hour; i++ {
+		ceiling *= 2
+	}
+	if limit := retry.MaxBackoff.Duration(); limit > 0 && ceiling > limit {
+		ceiling = limit
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling + 1)
+}
+
+// sleep waits for d, and reports false when ctx ends first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// nextBackend returns the next backend of the balancer, skipping those in tried. A round-robin balancer offers
+// another backend within one pick more than there are tried ones; when it does not, its first pick is returned.
+func nextBackend(balancer roundrobin.RoundRobinInterface, tried map[string]bool) (string, error) {
+	first, err := balancer.Next()
+	if err != nil || !tried[first] {
+		return first, err
+	}
+	for picks := 0; picks < len(tried); picks++ {
+		instance, err := balancer.Next()
+		if err != nil {
+			return "", err
+		}
+		if !tried[instance] {
+			return instance, nil
+		}
+	}
+	return first, nil
+}
+
+// connectFailed reports whether err shows the connection to the backend could not be made, so nothing was sent to it.
+func connectFailed(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
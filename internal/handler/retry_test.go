@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+	"github.com/samargupta114/Roundrobinator.git/pkg/utils/httpclient"
+)
+
+func TestRetryBudget(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	budget := &RetryBudget{now: func() time.Time { return now }}
+
+	// A few retries are allowed whatever the traffic
+	for i := 0; i < budgetMinRetries; i++ {
+		if !budget.Retry(10) {
+			t.Fatalf("Expected retry %d to be allowed", i+1)
+		}
+	}
+	if budget.Retry(10) {
+		t.Errorf("Expected the retry over the minimum to be refused")
+	}
+
+	// 10% of 50 requests allows 5 retries in all
+	for i := 0; i < 50; i++ {
+		budget.Request()
+	}
+	if !budget.Retry(10) || !budget.Retry(10) {
+		t.Errorf("Expected retries within 10%% of the requests to be allowed")
+	}
+	if budget.Retry(10) {
+		t.Errorf("Expected the retry over 10%% of the requests to be refused")
+	}
+	if !budget.Retry(0) {
+		t.Errorf("Expected a budget of 0%% to allow every retry")
+	}
+
+	// Counts are kept for one more window, then forgotten
+	now = now.Add(budgetWindow)
+	if budget.Retry(10) {
+		t.Errorf("Expected the retries of the previous window to still count")
+	}
+	now = now.Add(budgetWindow)
+	if !budget.Retry(10) {
+		t.Errorf("Expected the retries of older windows to be forgotten")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	retry := config.Retry{Backoff: config.Duration(100 * time.Millisecond), MaxBackoff: config.Duration(300 * time.Millisecond)}
+	tests := []struct {
+		retry    config.Retry
+		n        int
+		expected time.Duration // Longest wait
+	}{
+		{retry: config.Retry{}, n: 1, expected: 0},
+		{retry: retry, n: 1, expected: 100 * time.Millisecond},
+		{retry: retry, n: 2, expected: 200 * time.Millisecond},
+		{retry: retry, n: 3, expected: 300 * time.Millisecond},
+		{retry: retry, n: 60, expected: 300 * time.Millisecond},
+		{retry: config.Retry{Backoff: config.Seconds(1)}, n: 60, expected: 2 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if d := backoff(tt.retry, tt.n); d < 0 || d > tt.expected {
+				t.Errorf("Expected retry %d to wait between 0 and %s, got %s", tt.n, tt.expected, d)
+				break
+			}
+		}
+	}
+}
+
+func TestNextBackend(t *testing.T) {
+	rr := &MockRoundRobin{ports: []string{"8081", "8082", "8083"}}
+	tried := map[string]bool{"8081": true, "8082": true}
+	if instance, _ := nextBackend(rr, tried); instance != "8083" {
+		t.Errorf("Expected the untried backend 8083, got %s", instance)
+	}
+
+	// When every backend was tried, the first pick is taken
+	rr = &MockRoundRobin{ports: []string{"8081", "8082"}}
+	if instance, _ := nextBackend(rr, tried); instance != "8081" {
+		t.Errorf("Expected the first pick 8081, got %s", instance)
+	}
+
+	rr = &MockRoundRobin{err: errors.New("no healthy instances available")}
+	if _, err := nextBackend(rr, tried); err == nil {
+		t.Errorf("Expected the error of the balancer")
+	}
+}
+
+// TestRoutingHandler_Retries tests retries against real backends, one of which refuses connections.
+func TestRoutingHandler_Retries(t *testing.T) {
+	// A port nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := "http://" + listener.Addr().String()
+	listener.Close()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unavailable" {
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer backend.Close()
+
+	// A backend that reads the whole body before failing
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	large := strings.Repeat("x", replayBufferBytes+1)
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		body               string // "payload" when empty
+		backends           []string
+		retry              config.Retry
+		budget             *RetryBudget
+		expectedStatusCode int
+		expectedBody       string
+		expectedAttempts   string
+	}{
+		{
+			name:               "Idempotent request retried on another backend",
+			method:             http.MethodPut,
+			path:               "/echo",
+			backends:           []string{refused, backend.URL},
+			retry:              config.Retry{Attempts: 3},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "payload",
+			expectedAttempts:   "2",
+		},
+		{
+			name:               "Non-idempotent request retried when the connection is refused",
+			method:             http.MethodPost,
+			path:               "/echo",
+			backends:           []string{refused, backend.URL},
+			retry:              config.Retry{Attempts: 3, Backoff: config.Duration(time.Millisecond)},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "payload",
+			expectedAttempts:   "2",
+		},
+		{
+			name:               "Non-idempotent request not retried once sent",
+			method:             http.MethodPost,
+			path:               "/unavailable",
+			backends:           []string{backend.URL, refused},
+			retry:              config.Retry{Attempts: 3, Statuses: []int{http.StatusServiceUnavailable}},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedBody:       "Service Unavailable\n",
+			expectedAttempts:   "1",
+		},
+		{
+			name:               "Attempts cap",
+			method:             http.MethodGet,
+			path:               "/unavailable",
+			backends:           []string{backend.URL},
+			retry:              config.Retry{Attempts: 3, Statuses: []int{http.StatusServiceUnavailable}},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedBody:       "Service Unavailable\n",
+			expectedAttempts:   "3",
+		},
+		{
+			name:               "Body sent again on a retry status",
+			method:             http.MethodPut,
+			path:               "/echo",
+			backends:           []string{failing.URL, backend.URL},
+			retry:              config.Retry{Attempts: 3, Statuses: []int{http.StatusServiceUnavailable}},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "payload",
+			expectedAttempts:   "2",
+		},
+		{
+			name:               "Body over the replay buffer not sent again",
+			method:             http.MethodPut,
+			path:               "/echo",
+			body:               large,
+			backends:           []string{failing.URL, backend.URL},
+			retry:              config.Retry{Attempts: 3, Statuses: []int{http.StatusServiceUnavailable}},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedBody:       "Service Unavailable\n",
+			expectedAttempts:   "1",
+		},
+		{
+			name:               "Body over the replay buffer retried when the connection is refused",
+			method:             http.MethodPut,
+			path:               "/echo",
+			body:               large,
+			backends:           []string{refused, backend.URL},
+			retry:              config.Retry{Attempts: 3},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       large,
+			expectedAttempts:   "2",
+		},
+		{
+			name:               "Retry budget spent",
+			method:             http.MethodGet,
+			path:               "/echo",
+			backends:           []string{refused, backend.URL},
+			retry:              config.Retry{Attempts: 3, BudgetPercent: 10},
+			budget:             &RetryBudget{start: time.Now(), current: budgetCounts{retries: budgetMinRetries}},
+			expectedStatusCode: http.StatusBadGateway,
+			expectedBody:       "Error forwarding request\n",
+			expectedAttempts:   "1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RoutingHandler(func(r *http.Request) (Pool, bool) {
+				return Pool{
+					Balancer: &MockRoundRobin{ports: tt.backends},
+					Client:   httpclient.New(httpclient.Options{}),
+					Policy:   config.Policy{Retry: tt.retry},
+					Budget:   tt.budget,
+				}, true
+			})
+
+			body := tt.body
+			if body == "" {
+				body = "payload"
+			}
+			rr := httptest.NewRecorder()
+			handler(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(body)))
+
+			if rr.Code != tt.expectedStatusCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatusCode, rr.Code)
+			}
+			if rr.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body of %d bytes, got %d", len(tt.expectedBody), rr.Body.Len())
+			}
+			if got := rr.Header().Get(headerAttempts); got != tt.expectedAttempts {
+				t.Errorf("Expected %s %q, got %q", headerAttempts, tt.expectedAttempts, got)
+			}
+		})
+	}
+}
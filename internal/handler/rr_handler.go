@@ -1,9 +1,17 @@
 package handler
 
 import (
+	"errors"
 	"io"
+	"log"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
 	"github.com/samargupta114/Roundrobinator.git/internal/roundrobin"
 	"github.com/samargupta114/Roundrobinator.git/pkg/utils/httpclient"
 )
@@ -13,45 +21,260 @@ func sendErrorResponse(w http.ResponseWriter, msg string, statusCode int) {
 	http.Error(w, msg, statusCode) // Send the HTTP error response.
 }
 
+// Pool is a set of backends requests can be routed to: the balancer picking the backend, the client forwarding
+// to it, the policy limiting the body size and retries of the requests, the rewriting of their paths, the proxies
+// whose forwarded headers are trusted, and the budget its retries are taken from.
+type Pool struct {
+	Balancer roundrobin.RoundRobinInterface
+	Client   httpclient.ClientInterface
+	Policy   config.Policy
+
+	StripPrefix string // Removed from the start of the request path, at a segment boundary
+	AddPrefix   string // Put in front of the request path, once StripPrefix is removed
+
+	TrustedProxies []netip.Prefix // Peers whose X-Forwarded-* and Forwarded headers are kept; nil trusts no one
+
+	Budget *RetryBudget // Retries of the pool, shared by its requests; nil leaves them unlimited
+}
+
+// Path prefixes of the legacy /route endpoint, which forwards to the /mirror endpoint of the Application API.
+const (
+	routePrefix  = "/route"
+	mirrorPrefix = "/mirror"
+)
+
+// PoolFunc returns the pool a request is routed to, or false when no pool serves it.
+type PoolFunc func(r *http.Request) (Pool, bool)
+
 // RouteHandler handles forwarding HTTP requests using Round Robin to application instances.
+// Requests to /route and below are forwarded to the same path below /mirror, with their query:
+// /route/anything?x=1 goes to /mirror/anything?x=1.
 // rr: RoundRobinInterface for selecting the next server instance.
 // client: ClientInterface to forward the HTTP request to the chosen instance.
 func RouteHandler(rr roundrobin.RoundRobinInterface, client httpclient.ClientInterface) http.HandlerFunc {
+	return PoolRouteHandler(func(*http.Request) (Pool, bool) {
+		return Pool{Balancer: rr, Client: client}, true
+	})
+}
+
+// PoolRouteHandler is RouteHandler over the pool picked by pools, whose path rewriting is replaced by
+// that of the /route endpoint. Requests no pool serves are answered with 404.
+func PoolRouteHandler(pools PoolFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Get the next instance/port from the Round Robin mechanism.
-		port, err := rr.Next()
+		pool, ok := pools(r)
+		if !ok {
+			sendErrorResponse(w, "No backend pool serves this request", http.StatusNotFound)
+			return
+		}
+		pool.StripPrefix, pool.AddPrefix = routePrefix, mirrorPrefix
+		forward(w, r, pool, targetPath(r.URL, pool))
+	}
+}
+
+// RoutingHandler forwards every HTTP request, with its path and query, to a backend of the pool picked by pools,
+// rewriting the path as the pool asks. Requests no pool serves are answered with 404.
+func RoutingHandler(pools PoolFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pool, ok := pools(r)
+		if !ok {
+			sendErrorResponse(w, "No backend pool serves this request", http.StatusNotFound)
+			return
+		}
+		forward(w, r, pool, targetPath(r.URL, pool))
+	}
+}
+
+// targetPath returns the path and query a request to u is forwarded to on a backend of the pool:
+// its own path, escaped as received, without the strip prefix of the pool and behind its add prefix.
+func targetPath(u *url.URL, pool Pool) string {
+	path := u.EscapedPath()
+	if rest, ok := strings.CutPrefix(path, pool.StripPrefix); ok && pool.StripPrefix != "" &&
+		(rest == "" || rest[0] == '/' || strings.HasSuffix(pool.StripPrefix, "/")) {
+		path = rest
+	}
+	if path != "" && path[0] != '/' {
+		path = "/" + path
+	}
+	if pool.AddPrefix != "" {
+		path = strings.TrimSuffix(pool.AddPrefix, "/") + path
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
+
+// forward sends the request to path on the next backend of the pool and streams the response back.
+// Failed requests are retried on other backends of the pool as allowed by its retry policy and budget: idempotent
+// requests when they fail or get a retry status, others only when no backend could be connected to. The response
+// tells how many backends were tried in the X-Upstream-Attempts header.
+// The request is forwarded without its hop-by-hop headers, and with forwarded headers identifying the client.
+func forward(w http.ResponseWriter, r *http.Request, pool Pool, path string) {
+	// Forward a copy of the request, so the headers of the incoming one are left as received.
+	// Hop-by-hop headers go first: a Connection header could otherwise name the forwarded headers added next.
+	r = r.Clone(r.Context())
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	httpclient.RemoveHopByHop(r.Header)
+	setForwardedHeaders(r.Header, r, pool.TrustedProxies)
+
+	// Reject bodies over the limit before anything is forwarded, and cut off those that turn out to be over it
+	if r.Body == nil {
+		r.Body = http.NoBody
+	}
+	if limit := pool.Policy.MaxBodyBytes; limit > 0 {
+		if r.ContentLength > limit {
+			sendErrorResponse(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+
+	retryPolicy := pool.Policy.Retry
+	attempts := max(retryPolicy.Attempts, 1)
+	idempotent := isIdempotent(r.Method)
+	if pool.Budget != nil {
+		pool.Budget.Request()
+	}
+
+	// The body is streamed to the backend as it arrives. Idempotent requests keep its start, so a retry can send
+	// it again as long as it fits in the replay buffer; past that, and for other requests, a retry is only possible
+	// while none of the body was sent.
+	var replayLimit int64
+	if idempotent && attempts > 1 {
+		replayLimit = replayBufferBytes
+	}
+	requestBody := newRequestBody(r.Body, replayLimit)
+
+	var resp *http.Response
+	tried := make(map[string]bool)
+	for attempt := 1; ; attempt++ {
+		// Get the next instance from the Round Robin mechanism, another one than those tried already.
+		instance, err := nextBackend(pool.Balancer, tried)
 		if err != nil {
 			// If an error occurred, send a 500 error response.
 			sendErrorResponse(w, "Error getting next round-robin instance", http.StatusInternalServerError)
 			return
 		}
+		tried[instance] = true
 
-		// Construct the target URL for the request to the chosen instance.
-		url := "http://localhost:" + port + "/mirror"
+		// Construct the target URL for the request to the chosen instance, a bare port or a base URL.
+		target := config.BackendURL(instance) + path
 
 		// Forward the request to the target instance.
-		resp, err := client.ForwardRequest(r, url)
+		r.Body = requestBody.Attempt()
+		w.Header().Set(headerAttempts, strconv.Itoa(attempt))
+		resp, err = pool.Client.ForwardRequest(r, target)
+
+		// Any request can be retried when the backend got none of it; idempotent ones also when it failed or got
+		// a retry status, as long as the body can be sent again
+		retry := err != nil && connectFailed(err) && requestBody.Untouched()
+		if idempotent && !retry {
+			retry = (err != nil || slices.Contains(retryPolicy.Statuses, resp.StatusCode)) && requestBody.Replayable()
+		}
+		if retry && attempt < attempts && pool.Budget != nil && !pool.Budget.Retry(retryPolicy.BudgetPercent) {
+			log.Printf("Not retrying request to %s: the retry budget of the pool is spent", r.URL.Path)
+			retry = false
+		}
+		if !retry || attempt == attempts {
+			if err != nil {
+				sendForwardError(w, err, requestBody)
+				return
+			}
+			break
+		}
+
 		if err != nil {
-			// If forwarding fails, send a 502 Bad Gateway error response.
-			sendErrorResponse(w, "Error forwarding request", http.StatusBadGateway)
+			log.Printf("Retrying request to %s after attempt %d of %d failed: %v", r.URL.Path, attempt, attempts, err)
+		} else {
+			log.Printf("Retrying request to %s after attempt %d of %d got status %d", r.URL.Path, attempt, attempts, resp.StatusCode)
+			_, _ = io.Copy(io.Discard, resp.Body) // Drain the body so the connection can be reused
+			resp.Body.Close()
+		}
+		if !sleep(r.Context(), backoff(retryPolicy, attempt)) {
+			sendForwardError(w, r.Context().Err(), requestBody) // The client is gone
 			return
 		}
-		defer resp.Body.Close() // Ensure the response body is closed after streaming.
+	}
+	defer resp.Body.Close() // Ensure the response body is closed after streaming.
 
-		// Copy headers from the target response to the client response.
-		for key, values := range resp.Header {
-			for _, value := range values {
-				w.Header().Add(key, value) // Add each header to the response.
-			}
+	copyResponse(w, r, resp)
+}
+
+// copyResponse sends the backend response to the client as received: status code, end-to-end headers,
+// Content-Length, body and trailers. When the body fails mid-stream the response has already started,
+// so the connection is aborted instead, and the client sees a truncated response rather than a complete one.
+func copyResponse(w http.ResponseWriter, r *http.Request, resp *http.Response) {
+	// Copy headers from the target response to the client response, except the hop-by-hop ones.
+	respHeader := resp.Header.Clone()
+	httpclient.RemoveHopByHop(respHeader)
+	header := w.Header()
+	for key, values := range respHeader {
+		for _, value := range values {
+			header.Add(key, value) // Add each header to the response.
 		}
+	}
 
-		//_, err = w.Write(body) can also use this to direct write
+	// Announce the trailers, which follow a chunked body, or else keep the length of the body known
+	header.Del("Content-Length")
+	header.Del("Trailer")
+	for key := range resp.Trailer {
+		header.Add("Trailer", key)
+	}
+	if len(resp.Trailer) == 0 && resp.ContentLength >= 0 && bodyAllowed(resp.StatusCode) {
+		header.Set("Content-Length", strconv.FormatInt(resp.ContentLength, 10))
+	}
 
-		// Stream the response body directly to the client.
-		if _, err := io.Copy(w, resp.Body); err != nil {
-			// If streaming fails, send a 500 error response.
-			http.Error(w, "Error streaming response body", http.StatusInternalServerError)
-			return
-		}
+	w.WriteHeader(resp.StatusCode)
+
+	//_, err = w.Write(body) can also use this to direct write
+
+	// Stream the response body directly to the client.
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		// push alerts
+		log.Printf("Aborting response to %s after the body failed mid-stream: %v", r.URL.Path, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	// Trailers are only known once the body is read
+	for key, values := range resp.Trailer {
+		header[key] = values
+	}
+}
+
+// bodyAllowed reports whether a response with the given status code can have a body.
+func bodyAllowed(statusCode int) bool {
+	return statusCode >= http.StatusOK && statusCode != http.StatusNoContent && statusCode != http.StatusNotModified
+}
+
+// sendForwardError answers a request that could not be forwarded: 413 when its body is over the limit, 400 when
+// reading its body failed otherwise, and 502 when the backend failed.
+func sendForwardError(w http.ResponseWriter, err error, body *requestBody) {
+	bodyErr := body.Err()
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(bodyErr, &maxBytesErr) || errors.As(err, &maxBytesErr) {
+		sendErrorResponse(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if bodyErr != nil {
+		log.Printf("Failed to read the request body: %v", bodyErr)
+		sendErrorResponse(w, "Error reading request body", http.StatusBadRequest)
+		return
+	}
+	// If forwarding fails, send a 502 Bad Gateway error response.
+	sendErrorResponse(w, "Error forwarding request", http.StatusBadGateway)
+}
+
+// isIdempotent reports whether a request with the given method can safely be sent more than once.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
 	}
+	return false
 }
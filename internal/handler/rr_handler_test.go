@@ -5,8 +5,12 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+	"github.com/samargupta114/Roundrobinator.git/pkg/utils/httpclient"
 )
 
 // MockRoundRobin is a mock implementation of RoundRobin for testing.
@@ -29,9 +33,11 @@ func (m *MockRoundRobin) Next() (string, error) {
 type MockHttpClient struct {
 	resp *http.Response
 	err  error
+	url  string // Target URL of the last forwarded request
 }
 
 func (m *MockHttpClient) ForwardRequest(r *http.Request, url string) (*http.Response, error) {
+	m.url = url
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -47,6 +53,7 @@ func TestRouteHandler(t *testing.T) {
 		forwardRequestErr  error
 		expectedStatusCode int
 		expectedBody       string
+		expectedAbort      bool
 	}{
 		{
 			name:               "Round Robin Next Error",
@@ -85,8 +92,22 @@ func TestRouteHandler(t *testing.T) {
 				Body:       io.NopCloser(&errorReader{}), // Simulate error during body read
 			},
 			forwardRequestErr:  nil,
-			expectedStatusCode: http.StatusInternalServerError,
-			expectedBody:       "Error streaming response body\n",
+			expectedStatusCode: http.StatusOK, // Already sent when the body fails
+			expectedBody:       "",
+			expectedAbort:      true,
+		},
+		{
+			name:            "Backend Error Status",
+			roundRobinError: nil,
+			forwardRequestResp: &http.Response{
+				StatusCode:    http.StatusBadRequest,
+				Header:        http.Header{"Content-Type": []string{"text/plain"}},
+				Body:          io.NopCloser(strings.NewReader("missing field")),
+				ContentLength: int64(len("missing field")),
+			},
+			forwardRequestErr:  nil,
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       "missing field",
 		},
 	}
 
@@ -110,8 +131,21 @@ func TestRouteHandler(t *testing.T) {
 			// Create the handler function
 			handler := RouteHandler(mockRoundRobin, mockHttpClient)
 
-			// Call the handler
-			handler.ServeHTTP(rr, req)
+			// Call the handler; a mid-stream failure aborts the connection by panicking with http.ErrAbortHandler
+			func() {
+				defer func() {
+					if recovered := recover(); recovered != nil {
+						if !tt.expectedAbort || recovered != http.ErrAbortHandler {
+							panic(recovered)
+						}
+						return
+					}
+					if tt.expectedAbort {
+						t.Errorf("Expected the response to be aborted")
+					}
+				}()
+				handler.ServeHTTP(rr, req)
+			}()
 
 			// Check status code
 			if rr.Code != tt.expectedStatusCode {
@@ -126,6 +160,365 @@ func TestRouteHandler(t *testing.T) {
 	}
 }
 
+// TestRoutingHandler_Response tests that backend responses reach the client as sent, through real connections.
+func TestRoutingHandler_Response(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/teapot":
+			w.Header().Set("X-Backend", "teapot")
+			w.WriteHeader(http.StatusTeapot)
+			_, _ = io.WriteString(w, "short and stout")
+		case "/fail":
+			http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/trailers":
+			w.Header().Set("Trailer", "X-Checksum")
+			_, _ = io.WriteString(w, "payload")
+			w.Header().Set("X-Checksum", "c0ffee")
+		case "/truncated":
+			w.Header().Set("Content-Length", "100")
+			_, _ = io.WriteString(w, "only part of it")
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler) // Drop the connection mid-body
+		}
+	}))
+	defer backend.Close()
+
+	proxy := httptest.NewServer(RoutingHandler(func(r *http.Request) (Pool, bool) {
+		return Pool{Balancer: &MockRoundRobin{ports: []string{backend.URL}}, Client: httpclient.New(httpclient.Options{})}, true
+	}))
+	defer proxy.Close()
+
+	tests := []struct {
+		name                  string
+		path                  string
+		expectedStatusCode    int
+		expectedBody          string
+		expectedContentLength int64
+		expectedHeader        http.Header
+		expectedTrailer       http.Header
+	}{
+		{name: "Status and headers", path: "/teapot", expectedStatusCode: http.StatusTeapot, expectedBody: "short and stout",
+			expectedContentLength: 15, expectedHeader: http.Header{"X-Backend": {"teapot"}}},
+		{name: "Error status", path: "/fail", expectedStatusCode: http.StatusServiceUnavailable, expectedBody: "database unavailable\n",
+			expectedContentLength: 21, expectedHeader: http.Header{"Content-Type": {"text/plain; charset=utf-8"}}},
+		{name: "No content", path: "/empty", expectedStatusCode: http.StatusNoContent, expectedContentLength: 0},
+		{name: "Trailers", path: "/trailers", expectedStatusCode: http.StatusOK, expectedBody: "payload", expectedContentLength: -1,
+			expectedTrailer: http.Header{"X-Checksum": {"c0ffee"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(proxy.URL + tt.path)
+			if err != nil {
+				t.Fatalf("Expected a response, got %v", err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Expected the whole body, got %v", err)
+			}
+
+			if resp.StatusCode != tt.expectedStatusCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatusCode, resp.StatusCode)
+			}
+			if string(body) != tt.expectedBody {
+				t.Errorf("Expected response body %q, got %q", tt.expectedBody, body)
+			}
+			if resp.ContentLength != tt.expectedContentLength {
+				t.Errorf("Expected Content-Length %d, got %d", tt.expectedContentLength, resp.ContentLength)
+			}
+			for key := range tt.expectedHeader {
+				if got := resp.Header.Get(key); got != tt.expectedHeader.Get(key) {
+					t.Errorf("Expected header %s %q, got %q", key, tt.expectedHeader.Get(key), got)
+				}
+			}
+			for key := range tt.expectedTrailer {
+				if got := resp.Trailer.Get(key); got != tt.expectedTrailer.Get(key) {
+					t.Errorf("Expected trailer %s %q, got %q", key, tt.expectedTrailer.Get(key), got)
+				}
+			}
+		})
+	}
+
+	// A body failing mid-stream aborts the connection, so the client cannot mistake it for a complete response.
+	// Whatever was buffered is dropped with it, so the failure can show before the headers or within the body.
+	t.Run("Truncated body", func(t *testing.T) {
+		resp, err := http.Get(proxy.URL + "/truncated")
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		if body, err := io.ReadAll(resp.Body); err == nil {
+			t.Errorf("Expected reading the body to fail, got status %d with %q", resp.StatusCode, body)
+		}
+	})
+}
+
+// TestRouteHandler_BackendURL tests that requests are forwarded to bare ports on localhost and to full backend URLs.
+func TestRouteHandler_BackendURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		instance    string
+		target      string
+		expectedURL string
+	}{
+		{name: "Bare port", instance: "8081", target: "/route", expectedURL: "http://localhost:8081/mirror"},
+		{name: "URL with base path", instance: "https://api.internal:8443/v1/", target: "/route", expectedURL: "https://api.internal:8443/v1/mirror"},
+		{name: "Path and query", instance: "8081", target: "/route/anything?x=1", expectedURL: "http://localhost:8081/mirror/anything?x=1"},
+		{name: "Query only", instance: "8081", target: "/route?x=1&y=%20", expectedURL: "http://localhost:8081/mirror?x=1&y=%20"},
+		{name: "Trailing slash", instance: "8081", target: "/route/", expectedURL: "http://localhost:8081/mirror/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockHttpClient := &MockHttpClient{
+				resp: &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))},
+			}
+			handler := RouteHandler(&MockRoundRobin{ports: []string{tt.instance}}, mockHttpClient)
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, tt.target, nil))
+
+			if mockHttpClient.url != tt.expectedURL {
+				t.Errorf("Expected request to be forwarded to %q, got %q", tt.expectedURL, mockHttpClient.url)
+			}
+		})
+	}
+}
+
+// TestRoutingHandler tests that requests keep their path and query and go to the pool picked for them.
+func TestRoutingHandler(t *testing.T) {
+	pools := map[string]*MockHttpClient{
+		"api": {resp: &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("api"))}},
+		"web": {resp: &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("web"))}},
+	}
+	balancers := map[string]*MockRoundRobin{
+		"api": {ports: []string{"https://api.internal/v1"}},
+		"web": {ports: []string{"8081"}},
+	}
+	handler := RoutingHandler(func(r *http.Request) (Pool, bool) {
+		name := "web"
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			name = "api"
+		} else if r.URL.Path == "/missing" {
+			return Pool{}, false
+		}
+		pool := Pool{Balancer: balancers[name], Client: pools[name]}
+		if name == "api" {
+			pool.StripPrefix, pool.AddPrefix = "/api", "/service"
+		}
+		return pool, true
+	})
+
+	tests := []struct {
+		name               string
+		target             string
+		client             *MockHttpClient
+		expectedStatusCode int
+		expectedBody       string
+		expectedURL        string
+	}{
+		{name: "Path and query with rewritten prefix", target: "/api/users?page=2&sort=name", client: pools["api"], expectedStatusCode: http.StatusOK, expectedBody: "api", expectedURL: "https://api.internal/v1/service/users?page=2&sort=name"},
+		{name: "Escaped path", target: "/files/a%2Fb", client: pools["web"], expectedStatusCode: http.StatusOK, expectedBody: "web", expectedURL: "http://localhost:8081/files/a%2Fb"},
+		{name: "No pool", target: "/missing", expectedStatusCode: http.StatusNotFound, expectedBody: "No backend pool serves this request\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+			if body := w.Body.String(); body != tt.expectedBody {
+				t.Errorf("Expected response body %q, got %q", tt.expectedBody, body)
+			}
+			if tt.client != nil && tt.client.url != tt.expectedURL {
+				t.Errorf("Expected request to be forwarded to %q, got %q", tt.expectedURL, tt.client.url)
+			}
+		})
+	}
+}
+
+// TestPoolRouteHandler_NoPool tests that the /route endpoint answers requests no pool serves like the routing handler.
+func TestPoolRouteHandler_NoPool(t *testing.T) {
+	handler := PoolRouteHandler(func(*http.Request) (Pool, bool) { return Pool{}, false })
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/route/x", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if expected, body := "No backend pool serves this request\n", w.Body.String(); body != expected {
+		t.Errorf("Expected response body %q, got %q", expected, body)
+	}
+}
+
+// TestTargetPath tests how the strip and add prefixes of a pool rewrite the forwarded path.
+func TestTargetPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		strip    string
+		add      string
+		expected string
+	}{
+		{name: "Unchanged", target: "/api/users?page=2", expected: "/api/users?page=2"},
+		{name: "Strip prefix", target: "/billing/invoices?id=7", strip: "/billing", expected: "/invoices?id=7"},
+		{name: "Strip the whole path", target: "/billing", strip: "/billing", expected: "/"},
+		{name: "Strip prefix with trailing slash", target: "/billing/invoices", strip: "/billing/", expected: "/invoices"},
+		{name: "Strip only at a segment boundary", target: "/billings/invoices", strip: "/billing", expected: "/billings/invoices"},
+		{name: "Strip prefix not matching", target: "/shop/cart", strip: "/billing", expected: "/shop/cart"},
+		{name: "Add prefix", target: "/users", add: "/v2", expected: "/v2/users"},
+		{name: "Add prefix with trailing slash", target: "/users", add: "/v2/", expected: "/v2/users"},
+		{name: "Strip and add prefixes", target: "/billing/invoices?id=7", strip: "/billing", add: "/internal/billing-svc", expected: "/internal/billing-svc/invoices?id=7"},
+		{name: "Escaped path", target: "/files/a%2Fb", strip: "/files", add: "/storage", expected: "/storage/a%2Fb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if path := targetPath(r.URL, Pool{StripPrefix: tt.strip, AddPrefix: tt.add}); path != tt.expected {
+				t.Errorf("Expected path %q, got %q", tt.expected, path)
+			}
+		})
+	}
+}
+
+// sequenceClient is a mock client answering every forwarded request with the next of its responses.
+type sequenceClient struct {
+	responses []*http.Response // nil entries fail to reach the backend
+	urls      []string         // Target URL of every forwarded request
+	bodies    []string         // Body of every forwarded request
+}
+
+func (m *sequenceClient) ForwardRequest(r *http.Request, url string) (*http.Response, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.urls = append(m.urls, url)
+	m.bodies = append(m.bodies, string(body))
+
+	resp := m.responses[len(m.urls)-1]
+	if resp == nil {
+		return nil, errors.New("connection refused")
+	}
+	return resp, nil
+}
+
+// response returns a backend response with the given status code and body.
+func response(statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode:    statusCode,
+		Header:        http.Header{},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+}
+
+// TestRoutingHandler_Policy tests that the body size limit and retry policy of the pool are enforced.
+func TestRoutingHandler_Policy(t *testing.T) {
+	retry := config.Retry{Attempts: 3, Statuses: []int{http.StatusServiceUnavailable}}
+
+	tests := []struct {
+		name               string
+		method             string
+		body               string
+		policy             config.Policy
+		responses          []*http.Response
+		expectedStatusCode int
+		expectedBody       string
+		expectedURLs       []string
+	}{
+		{
+			name:               "Body over the limit",
+			method:             http.MethodPost,
+			body:               "0123456789",
+			policy:             config.Policy{MaxBodyBytes: 4},
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+			expectedBody:       "Request body too large\n",
+		},
+		{
+			name:               "Body within the limit",
+			method:             http.MethodPost,
+			body:               "0123",
+			policy:             config.Policy{MaxBodyBytes: 4},
+			responses:          []*http.Response{response(http.StatusOK, "ok")},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "ok",
+			expectedURLs:       []string{"http://localhost:8081/"},
+		},
+		{
+			name:               "Retried on another backend",
+			method:             http.MethodPut,
+			body:               "payload",
+			policy:             config.Policy{Retry: retry},
+			responses:          []*http.Response{nil, response(http.StatusServiceUnavailable, "busy"), response(http.StatusOK, "ok")},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "ok",
+			expectedURLs:       []string{"http://localhost:8081/", "http://localhost:8082/", "http://localhost:8081/"},
+		},
+		{
+			name:               "Attempts exhausted",
+			method:             http.MethodGet,
+			policy:             config.Policy{Retry: config.Retry{Attempts: 2}},
+			responses:          []*http.Response{nil, nil},
+			expectedStatusCode: http.StatusBadGateway,
+			expectedBody:       "Error forwarding request\n",
+			expectedURLs:       []string{"http://localhost:8081/", "http://localhost:8082/"},
+		},
+		{
+			name:               "Last status passed through",
+			method:             http.MethodGet,
+			policy:             config.Policy{Retry: config.Retry{Attempts: 2, Statuses: retry.Statuses}},
+			responses:          []*http.Response{response(http.StatusServiceUnavailable, "busy"), response(http.StatusServiceUnavailable, "still busy")},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedBody:       "still busy",
+			expectedURLs:       []string{"http://localhost:8081/", "http://localhost:8082/"},
+		},
+		{
+			name:               "Non-idempotent requests are not retried",
+			method:             http.MethodPost,
+			policy:             config.Policy{Retry: retry},
+			responses:          []*http.Response{nil},
+			expectedStatusCode: http.StatusBadGateway,
+			expectedBody:       "Error forwarding request\n",
+			expectedURLs:       []string{"http://localhost:8081/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &sequenceClient{responses: tt.responses}
+			handler := RoutingHandler(func(r *http.Request) (Pool, bool) {
+				return Pool{Balancer: &MockRoundRobin{ports: []string{"8081", "8082"}}, Client: client, Policy: tt.policy}, true
+			})
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedStatusCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedStatusCode, w.Code)
+			}
+			if body := w.Body.String(); body != tt.expectedBody {
+				t.Errorf("Expected response body %q, got %q", tt.expectedBody, body)
+			}
+			if !slices.Equal(client.urls, tt.expectedURLs) {
+				t.Errorf("Expected requests to be forwarded to %q, got %q", tt.expectedURLs, client.urls)
+			}
+			for _, body := range client.bodies {
+				if body != tt.body {
+					t.Errorf("Expected every attempt to send body %q, got %q", tt.body, body)
+				}
+			}
+		})
+	}
+}
+
 // errorReader is a mock reader that simulates an error during Read.
 type errorReader struct{}
 
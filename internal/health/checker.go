@@ -0,0 +1,552 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+)
+
+const (
+	defaultWorkers           = 4                // Number of concurrent probes when none is configured
+	defaultHealthPath        = "/health"        // Health path used when the healthcheck endpoint is not configured
+	defaultInterval          = 30 * time.Second // Probe interval when the ticker time is not configured
+	defaultUnhealthyInterval = 5 * time.Second  // Probe interval for failing targets when none is configured
+	defaultHistorySize       = 50               // Health transitions kept per backend when none is configured
+	selfTargetName           = "roundrobin"     // Target name of the Round Robin API itself
+	maxHealthBodyBytes       = 64 << 10         // Upper bound for the health response body decoded by a probe
+)
+
+// Target states reported by the Checker.
+const (
+	StateUnknown   = "unknown"   // Not probed yet
+	StateHealthy   = "healthy"   // Latest probe succeeded
+	StateUnhealthy = "unhealthy" // Latest probe failed
+	StateDraining  = "draining"  // Latest probe succeeded but the target asked for no new traffic
+)
+
+// TargetStatus is the outcome of the latest probe of a single target.
+type TargetStatus struct {
+	Name      string            `json:"name"`             // Backend route
+	URL       string            `json:"url,omitempty"`    // Health check URL; empty for exec probes
+	Zone      string            `json:"zone,omitempty"`   // Zone of the backend
+	Labels    map[string]string `json:"labels,omitempty"` // Labels of the backend
+	State     string            `json:"state"`            // One of the State constants
+	LastCheck time.Time         `json:"last_check"`       // When the latest probe started; zero until the first probe
+	LatencyMs float64           `json:"latency_ms"`       // Duration of the latest probe in milliseconds
+	Reason    string            `json:"reason,omitempty"` // Why the latest probe failed
+	Output    string            `json:"output,omitempty"` // Captured stdout/stderr of the latest exec probe
+	Load      float64           `json:"load"`             // Load between 0 and 1 reported by the target
+}
+
+// target is a single URL or command probed by the Checker.
+type target struct {
+	name    string        // Backend route, or the name of the Round Robin API itself
+	url     string        // Health check URL; empty for exec probes
+	exec    *config.Probe // Command to run instead of an HTTP probe; nil for HTTP probes
+	route   config.Route  // Backend route, with its metadata
+	backend bool          // Whether the target is a backend that receives routed traffic
+	timeout time.Duration // Timeout of an HTTP probe; 0 falls back to the healthcheck endpoint timeout
+}
+
+// String describes the target for logs.
+func (t target) String() string {
+	if t.exec != nil {
+		return "exec " + commandLine(t.exec)
+	}
+	return t.url
+}
+
+// Checker probes the Round Robin API and every backend route on its own jittered schedule.
+// Healthy targets are probed every ticker interval, failing targets on the faster unhealthy interval.
+// The outcome of the latest probe of every target is kept for the readiness endpoints.
+// Update swaps the targets and settings while the checker runs.
+type Checker struct {
+	client  *http.Client
+	history *History // Health transitions of the backends
+
+	mu      sync.RWMutex
+	cfg     *config.Config
+	sem     chan struct{} // Bounds the number of concurrent probes
+	targets []target
+
+	interval          time.Duration
+	unhealthyInterval time.Duration
+	jitterPercent     int
+
+	statuses map[string]*TargetStatus // Latest probe outcome, keyed by target name
+
+	runCtx   context.Context               // Context of Run; nil until Run is called
+	stopped  bool                          // Whether Run stopped starting new probe loops
+	watchers map[string]context.CancelFunc // Stops the probe loop of every target, keyed by target name
+	wg       sync.WaitGroup                // Probe loops still running
+
+	panicMode func() bool // Reports whether the balancer ignores health; nil when unknown
+}
+
+// NewChecker creates a Checker for the given configuration.
+// client is used for every probe; per-probe deadlines come from the healthcheck endpoint timeout.
+func NewChecker(cfg *config.Config, client *http.Client) *Checker {
+	historySize := cfg.HealthCheck.HistorySize
+	if historySize <= 0 {
+		historySize = defaultHistorySize
+	}
+
+	c := &Checker{
+		client:   client,
+		history:  NewHistory(historySize),
+		targets:  buildTargets(cfg),
+		statuses: make(map[string]*TargetStatus),
+		watchers: make(map[string]context.CancelFunc),
+	}
+	c.configure(cfg)
+
+	// Every target starts in the unknown state until its first probe completes
+	for _, t := range c.targets {
+		c.statuses[t.name] = newStatus(t)
+	}
+	return c
+}
+
+// configure applies the probe and scheduling settings of cfg. The caller must hold c.mu or own c exclusively.
+func (c *Checker) configure(cfg *config.Config) {
+	workers := cfg.HealthCheck.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	if c.sem == nil || cap(c.sem) != workers {
+		c.sem = make(chan struct{}, workers) // Probes in flight release the semaphore they acquired
+	}
+
+	interval := cfg.HealthCheckInterval.Duration()
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	unhealthyInterval := cfg.HealthCheck.UnhealthyInterval.Duration()
+	if unhealthyInterval <= 0 {
+		unhealthyInterval = min(defaultUnhealthyInterval, interval)
+	}
+
+	c.cfg = cfg
+	c.interval = interval
+	c.unhealthyInterval = unhealthyInterval
+	c.jitterPercent = cfg.HealthCheck.JitterPercent
+}
+
+// newStatus returns the status of a target that has not been probed yet.
+func newStatus(t target) *TargetStatus {
+	return &TargetStatus{Name: t.name, URL: t.url, Zone: t.route.Zone, Labels: t.route.Labels, State: StateUnknown}
+}
+
+// Run probes every target until ctx is canceled.
+// Each target is probed once at startup and then on its own schedule.
+func (c *Checker) Run(ctx context.Context) {
+	c.mu.Lock()
+	c.runCtx = ctx
+	for _, t := range c.targets {
+		c.startWatch(t)
+	}
+	c.mu.Unlock()
+
+	<-ctx.Done()
+
+	c.mu.Lock()
+	c.stopped = true
+	c.mu.Unlock()
+	c.wg.Wait()
+}
+
+// startWatch starts the probe loop of a target if the checker is running. The caller must hold c.mu.
+func (c *Checker) startWatch(t target) {
+	if c.runCtx == nil || c.stopped {
+		return
+	}
+	ctx, cancel := context.WithCancel(c.runCtx)
+	c.watchers[t.name] = cancel
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.watch(ctx, t)
+	}()
+}
+
+// Update applies a new configuration while the checker runs.
+// Targets whose URL or probe is unchanged keep their status; removed targets stop being probed,
+// and new or changed ones start in the unknown state with an immediate probe.
+func (c *Checker) Update(cfg *config.Config) {
+	targets := buildTargets(cfg)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.configure(cfg)
+
+	previous := make(map[string]target, len(c.targets))
+	for _, t := range c.targets {
+		previous[t.name] = t
+	}
+
+	statuses := make(map[string]*TargetStatus, len(targets))
+	for _, t := range targets {
+		old, ok := previous[t.name]
+		delete(previous, t.name)
+
+		if ok && reflect.DeepEqual(old, t) {
+			statuses[t.name] = c.statuses[t.name]
+			continue
+		}
+
+		// Changed targets are probed from scratch; a new URL or probe says nothing of the old state
+		status := newStatus(t)
+		if ok && old.url == t.url && reflect.DeepEqual(old.exec, t.exec) {
+			kept := *c.statuses[t.name]
+			kept.Zone, kept.Labels = t.route.Zone, t.route.Labels
+			status = &kept
+		}
+		statuses[t.name] = status
+
+		if cancel, ok := c.watchers[t.name]; ok {
+			cancel()
+		}
+		c.startWatch(t)
+	}
+
+	// Stop probing removed targets
+	for name := range previous {
+		if cancel, ok := c.watchers[name]; ok {
+			cancel()
+			delete(c.watchers, name)
+		}
+	}
+
+	c.targets = targets
+	c.statuses = statuses
+}
+
+// buildTargets returns the health check targets of the Round Robin API and the backends of every pool.
+// A backend shared by several pools is probed once, with the health check of the first pool listing it.
+func buildTargets(cfg *config.Config) []target {
+	path := defaultHealthPath
+	if endpoint, ok := cfg.Backend.Endpoint[config.HealthcheckEndpoint]; ok && endpoint.URL != "" {
+		path = endpoint.URL
+	}
+
+	targets := []target{
+		{name: selfTargetName, url: "http://localhost:" + cfg.Server.Port + path}, // Round Robin API
+	}
+	pools := cfg.BackendPools()
+	seen := make(map[string]bool)
+	for _, name := range cfg.PoolNames() {
+		pool := pools[name]
+		poolPath := path
+		if pool.HealthCheck.URL != "" {
+			poolPath = pool.HealthCheck.URL
+		}
+		timeout := pool.HealthCheck.Timeout.Duration()
+
+		for _, route := range pool.Backends {
+			if seen[route.Name()] {
+				continue
+			}
+			seen[route.Name()] = true
+
+			t := target{name: route.Name(), url: route.BaseURL() + poolPath, route: route, backend: true, timeout: timeout}
+			if probe, ok := cfg.HealthCheck.Probes[route.Name()]; ok && probe.Type == "exec" {
+				if probe.Timeout == 0 {
+					probe.Timeout = pool.HealthCheck.Timeout
+				}
+				t.url = ""
+				t.timeout = 0
+				t.exec = &probe
+			}
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// watch runs the probe loop for a single target.
+func (c *Checker) watch(ctx context.Context, t target) {
+	// Spread the initial probes over the jitter window instead of firing them all at once
+	timer := time.NewTimer(c.initialDelay())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		healthy := c.check(ctx, t)
+		timer.Reset(c.nextInterval(healthy))
+	}
+}
+
+// check probes a single target once a worker slot is free, records and logs the result and reports whether it is healthy.
+func (c *Checker) check(ctx context.Context, t target) bool {
+	// Wait for a free worker slot
+	c.mu.RLock()
+	sem := c.sem
+	c.mu.RUnlock()
+	select {
+	case sem <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
+	defer func() { <-sem }()
+
+	res := result{checkedAt: time.Now()}
+	if t.exec != nil {
+		res.output, res.err = c.execProbe(ctx, t.exec)
+	} else {
+		res.report, res.err = c.probeWithin(ctx, t.url, t.timeout)
+	}
+	res.latency = time.Since(res.checkedAt)
+
+	// A probe cut short by shutdown says nothing about the target
+	if ctx.Err() != nil {
+		return false
+	}
+	c.record(t, res)
+
+	err := res.err
+	if err != nil {
+		//push alerts
+		log.Printf("Health check failed for %s: %v\n", t, err)
+		return false
+	}
+	if t.exec != nil {
+		log.Printf("Health check succeeded for %s\n", t)
+	} else {
+		log.Printf("Health check succeeded for %s: %d\n", t, http.StatusOK)
+	}
+	return true
+}
+
+// result is the outcome of a single probe.
+type result struct {
+	checkedAt time.Time      // When the probe started
+	latency   time.Duration  // How long the probe took
+	output    string         // Captured stdout/stderr of exec probes
+	report    HealthResponse // Health response reported by HTTP targets
+	err       error          // Why the probe failed
+}
+
+// record stores the outcome of a probe as the latest status of the target.
+func (c *Checker) record(t target, res result) {
+	status := &TargetStatus{
+		Name:      t.name,
+		URL:       t.url,
+		Zone:      t.route.Zone,
+		Labels:    t.route.Labels,
+		State:     StateHealthy,
+		LastCheck: res.checkedAt,
+		LatencyMs: float64(res.latency.Microseconds()) / 1000,
+		Output:    res.output,
+	}
+	switch {
+	case res.err != nil:
+		status.State = StateUnhealthy
+		status.Reason = res.err.Error()
+	case res.report.Status == statusDraining:
+		status.State = StateDraining
+	}
+	if res.report.Load != nil {
+		status.Load = min(max(*res.report.Load, 0), 1)
+	}
+
+	c.mu.Lock()
+	current, ok := c.statuses[t.name]
+	if !ok || !c.isCurrent(t) {
+		// The target was removed or changed by Update while it was being probed
+		c.mu.Unlock()
+		return
+	}
+	previous := current.State
+	c.statuses[t.name] = status
+	c.mu.Unlock()
+
+	if !t.backend || previous == status.State {
+		return
+	}
+
+	// Keep track of every backend transition
+	log.Printf("Backend %s transitioned from %s to %s", t.name, previous, status.State)
+	event := Event{
+		Time:      res.checkedAt,
+		Backend:   t.name,
+		From:      previous,
+		To:        status.State,
+		Reason:    status.Reason,
+		LatencyMs: status.LatencyMs,
+		Output:    status.Output,
+	}
+	if err := c.history.Add(event); err != nil {
+		log.Printf("Failed to persist health transition of %s: %v", t.name, err)
+	}
+}
+
+// isCurrent reports whether t is one of the targets currently checked. The caller must hold c.mu.
+func (c *Checker) isCurrent(t target) bool {
+	for _, current := range c.targets {
+		if current.name == t.name {
+			return reflect.DeepEqual(current, t)
+		}
+	}
+	return false
+}
+
+// Status returns the latest status of a target.
+// Targets that have not been probed yet, or are not known at all, are reported in the unknown state.
+func (c *Checker) Status(name string) TargetStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	status, ok := c.statuses[name]
+	if !ok {
+		return TargetStatus{Name: name, State: StateUnknown}
+	}
+	return *status
+}
+
+// ReportPanicMode makes the readiness endpoint report the balancer's panic mode through panicMode.
+func (c *Checker) ReportPanicMode(panicMode func() bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.panicMode = panicMode
+}
+
+// PersistHistory loads earlier health transitions from the JSONL file at path and appends new ones to it.
+func (c *Checker) PersistHistory(path string) error {
+	return c.history.Persist(path)
+}
+
+// History returns the recorded health transitions of every backend, oldest first.
+func (c *Checker) History() map[string][]Event {
+	return c.history.Events()
+}
+
+// Close releases the resources held by the checker, such as the health history file.
+func (c *Checker) Close() error {
+	return c.history.Close()
+}
+
+// Backends returns the latest status of every backend, in configuration order.
+func (c *Checker) Backends() []TargetStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	backends := make([]TargetStatus, 0, len(c.targets))
+	for _, t := range c.targets {
+		if t.backend {
+			backends = append(backends, *c.statuses[t.name])
+		}
+	}
+	return backends
+}
+
+// probeWithin issues a single health check request bounded by timeout, or by the healthcheck endpoint timeout when 0.
+// It returns the health response reported by the target, if it sent one.
+func (c *Checker) probeWithin(ctx context.Context, url string, timeout time.Duration) (HealthResponse, error) {
+	if timeout <= 0 {
+		timeout = c.config().Backend.Endpoint[config.HealthcheckEndpoint].Timeout.Duration()
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return HealthResponse{}, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return HealthResponse{}, err
+	}
+	defer resp.Body.Close() // Close the response body to avoid resource leakage
+
+	// Decode the health response if there is one, then drain the body so the connection can be reused
+	var health HealthResponse
+	decodeErr := json.NewDecoder(io.LimitReader(resp.Body, maxHealthBodyBytes)).Decode(&health)
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if decodeErr != nil {
+		health = HealthResponse{} // Not a health response; only the status code counts
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if failed := failedChecks(health); failed != "" {
+			return health, fmt.Errorf("unexpected status code %d (failed checks: %s)", resp.StatusCode, failed)
+		}
+		return health, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	// Targets may also report failure in the body of a 200 response
+	if health.Status == statusFail {
+		return health, fmt.Errorf("reported status %s (failed checks: %s)", health.Status, failedChecks(health))
+	}
+	return health, nil
+}
+
+// config returns the configuration the checker currently applies.
+func (c *Checker) config() *config.Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cfg
+}
+
+// initialDelay returns a random delay within the jitter window of the healthy interval.
+func (c *Checker) initialDelay() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	spread := jitterSpread(c.interval, c.jitterPercent)
+	if spread <= 0 {
+		return 0
+	}
+	return rand.N(spread + 1)
+}
+
+// nextInterval returns the jittered delay until the next probe of a target.
+func (c *Checker) nextInterval(healthy bool) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	base := c.interval
+	if !healthy {
+		base = c.unhealthyInterval
+	}
+	return jitter(base, c.jitterPercent)
+}
+
+// jitter randomizes d by up to +/- percent of its value.
+func jitter(d time.Duration, percent int) time.Duration {
+	spread := jitterSpread(d, percent)
+	if spread <= 0 {
+		return d
+	}
+	return d - spread + rand.N(2*spread+1)
+}
+
+// jitterSpread returns percent of d, capped at half of d so a jittered interval never drops to zero.
+func jitterSpread(d time.Duration, percent int) time.Duration {
+	if percent <= 0 || d <= 0 {
+		return 0
+	}
+	if percent > config.MaxJitterPercent {
+		percent = config.MaxJitterPercent
+	}
+	return d * time.Duration(percent) / 100
+}
@@ -0,0 +1,456 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockRoundTripper is a custom RoundTripper for mocking HTTP requests
+type MockRoundTripper struct {
+	ResponseMap map[string]int
+}
+
+// RoundTrip executes a single HTTP transaction and returns a mock response
+func (mrt *MockRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Check if the requested URL exists in the mock response map
+	if status, exists := mrt.ResponseMap[req.URL.String()]; exists {
+		return &http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(bytes.NewBufferString(http.StatusText(status))),
+		}, nil
+	}
+	// Return a generic error response if the URL is not found in the mock map
+	return &http.Response{
+		StatusCode: http.StatusInternalServerError,
+		Body:       io.NopCloser(bytes.NewBufferString("Server Error")),
+	}, nil
+}
+
+// blockingRoundTripper tracks concurrent requests and blocks each one until its context is done or release is closed.
+type blockingRoundTripper struct {
+	inFlight    int32
+	maxInFlight int32
+	release     chan struct{}
+}
+
+func (b *blockingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	current := atomic.AddInt32(&b.inFlight, 1)
+	defer atomic.AddInt32(&b.inFlight, -1)
+	for {
+		seen := atomic.LoadInt32(&b.maxInFlight)
+		if current <= seen || atomic.CompareAndSwapInt32(&b.maxInFlight, seen, current) {
+			break
+		}
+	}
+
+	select {
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	case <-b.release:
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString("OK"))}, nil
+	}
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCheck_WithRoundTripper(t *testing.T) {
+	// Create a mock configuration
+	cfg := &config.Config{
+		Server: config.Server{
+			Port: "8080",
+		},
+		Backend: config.Backend{
+			Routes: config.Routes("9090", "7070"),
+		},
+	}
+
+	// Define mock responses for specific URLs
+	mockRoundTripper := &MockRoundTripper{ResponseMap: map[string]int{
+		"http://localhost:8080/health": http.StatusOK,
+		"http://localhost:9090/health": http.StatusInternalServerError,
+		"http://localhost:7070/health": http.StatusOK,
+	}}
+
+	// Capture log output
+	var logOutput bytes.Buffer
+	log.SetOutput(&logOutput)
+	defer log.SetOutput(os.Stderr) // Reset log output after the test
+
+	checker := NewChecker(cfg, &http.Client{Transport: mockRoundTripper})
+
+	// Probe every target once
+	results := make(map[string]bool)
+	for _, target := range checker.targets {
+		results[target.url] = checker.check(context.Background(), target)
+	}
+
+	assert.Equal(t, map[string]bool{
+		"http://localhost:8080/health": true,
+		"http://localhost:9090/health": false,
+		"http://localhost:7070/health": true,
+	}, results)
+
+	// Only backends are reported, with the outcome of their latest probe
+	backends := checker.Backends()
+	assert.Len(t, backends, 2)
+	assert.Equal(t, "9090", backends[0].Name)
+	assert.Equal(t, StateUnhealthy, backends[0].State)
+	assert.Equal(t, "unexpected status code 500", backends[0].Reason)
+	assert.False(t, backends[0].LastCheck.IsZero())
+	assert.Equal(t, "7070", backends[1].Name)
+	assert.Equal(t, StateHealthy, backends[1].State)
+	assert.Empty(t, backends[1].Reason)
+
+	// Verify the expected log output
+	assert.Contains(t, logOutput.String(), "Health check succeeded for http://localhost:8080/health")
+	assert.Contains(t, logOutput.String(), "Health check succeeded for http://localhost:7070/health")
+	assert.Contains(t, logOutput.String(), "Health check failed for http://localhost:9090/health: unexpected status code 500")
+}
+
+func TestTargets_UsesConfiguredEndpoint(t *testing.T) {
+	remote := config.Route{URL: "https://api.internal:8443/v1/", Zone: "eu-west-1a"}
+	cfg := &config.Config{
+		Server: config.Server{Port: "8080"},
+		Backend: config.Backend{
+			Routes: []config.Route{{URL: "9090"}, remote},
+			Endpoint: map[string]config.Endpoint{
+				config.HealthcheckEndpoint: {URL: "/status"},
+			},
+		},
+	}
+
+	checker := NewChecker(cfg, http.DefaultClient)
+	assert.Equal(t, []target{
+		{name: selfTargetName, url: "http://localhost:8080/status"},
+		{name: "9090", url: "http://localhost:9090/status", route: config.Route{URL: "9090"}, backend: true},
+		{name: "https://api.internal:8443/v1/", url: "https://api.internal:8443/v1/status", route: remote, backend: true},
+	}, checker.targets)
+
+	// Backend metadata is part of the reported status
+	assert.Equal(t, "eu-west-1a", checker.Backends()[1].Zone)
+}
+
+func TestTargets_PerPool(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.Server{Port: "8080"},
+		Backend: config.Backend{
+			Routes:   config.Routes("9090"),
+			Endpoint: map[string]config.Endpoint{config.HealthcheckEndpoint: {URL: "/health", Timeout: config.Seconds(2)}},
+		},
+		Pools: map[string]config.Pool{
+			"api":    {Backends: config.Routes("9091", "9092"), HealthCheck: config.Endpoint{URL: "/ping", Timeout: config.Seconds(1)}},
+			"shared": {Backends: config.Routes("9090", "9093")},
+		},
+		HealthCheck: config.HealthCheck{Probes: map[string]config.Probe{"9092": {Type: "exec", Command: "/bin/true"}}},
+	}
+
+	checker := NewChecker(cfg, http.DefaultClient)
+	assert.Equal(t, []target{
+		{name: selfTargetName, url: "http://localhost:8080/health"},
+		{name: "9090", url: "http://localhost:9090/health", route: config.Route{URL: "9090"}, backend: true, timeout: 2 * time.Second},
+		{name: "9091", url: "http://localhost:9091/ping", route: config.Route{URL: "9091"}, backend: true, timeout: time.Second},
+		{name: "9092", route: config.Route{URL: "9092"}, backend: true, exec: &config.Probe{Type: "exec", Command: "/bin/true", Timeout: config.Seconds(1)}},
+		{name: "9093", url: "http://localhost:9093/health", route: config.Route{URL: "9093"}, backend: true, timeout: 2 * time.Second},
+	}, checker.targets)
+}
+
+func TestBackends_UnknownBeforeFirstProbe(t *testing.T) {
+	cfg := &config.Config{
+		Server:  config.Server{Port: "8080"},
+		Backend: config.Backend{Routes: config.Routes("8081", "8082")},
+	}
+
+	backends := NewChecker(cfg, http.DefaultClient).Backends()
+
+	assert.Len(t, backends, 2)
+	for _, backend := range backends {
+		assert.Equal(t, StateUnknown, backend.State)
+		assert.True(t, backend.LastCheck.IsZero())
+	}
+}
+
+func TestStatus(t *testing.T) {
+	cfg := &config.Config{
+		Server:  config.Server{Port: "8080"},
+		Backend: config.Backend{Routes: config.Routes("8081", "8082", "8083")},
+	}
+	checker := NewChecker(cfg, http.DefaultClient)
+
+	// Backends start in the unknown state
+	assert.Equal(t, StateUnknown, checker.Status("8081").State)
+	assert.Equal(t, StateUnknown, checker.Status("9999").State)
+
+	load := 1.7
+	checker.record(checker.targets[1], result{checkedAt: time.Now(), latency: time.Millisecond, err: errors.New("connection refused")})
+	checker.record(checker.targets[2], result{checkedAt: time.Now(), latency: time.Millisecond, report: HealthResponse{Status: "OK", Load: &load}})
+	checker.record(checker.targets[3], result{checkedAt: time.Now(), latency: time.Millisecond, report: HealthResponse{Status: "DRAINING"}})
+
+	assert.Equal(t, StateUnhealthy, checker.Status("8081").State)
+	assert.Equal(t, StateHealthy, checker.Status("8082").State)
+	assert.Equal(t, 1.0, checker.Status("8082").Load, "reported load is capped at 1")
+	assert.Equal(t, StateDraining, checker.Status("8083").State)
+
+	// Draining is a transition of its own
+	events := checker.History()["8083"]
+	assert.Len(t, events, 1)
+	assert.Equal(t, StateDraining, events[0].To)
+}
+
+func TestRun_BoundsConcurrency(t *testing.T) {
+	cfg := &config.Config{
+		Server:      config.Server{Port: "8080"},
+		Backend:     config.Backend{Routes: config.Routes("8081", "8082", "8083", "8084", "8085")},
+		HealthCheck: config.HealthCheck{Workers: 2},
+	}
+
+	transport := &blockingRoundTripper{release: make(chan struct{})}
+	checker := NewChecker(cfg, &http.Client{Transport: transport})
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checker.Run(ctx)
+		close(done)
+	}()
+
+	// Let the workers pick up their first targets before checking the bound
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&transport.maxInFlight))
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after the context was canceled")
+	}
+}
+
+func TestRun_ProbesAtStartup(t *testing.T) {
+	cfg := &config.Config{
+		Server:              config.Server{Port: "8080"},
+		Backend:             config.Backend{Routes: config.Routes("8081")},
+		HealthCheckInterval: config.Seconds(60),
+	}
+
+	var probes int32
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&probes, 1)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString("OK"))}, nil
+	})
+	checker := NewChecker(cfg, &http.Client{Transport: transport})
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	checker.Run(ctx)
+
+	// Both targets are probed right away instead of after the 60s interval
+	assert.Equal(t, int32(2), atomic.LoadInt32(&probes))
+}
+
+func TestNextInterval(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		healthy bool
+		min     time.Duration
+		max     time.Duration
+	}{
+		{
+			name:    "Healthy target without jitter",
+			cfg:     config.Config{HealthCheckInterval: config.Seconds(45)},
+			healthy: true,
+			min:     45 * time.Second,
+			max:     45 * time.Second,
+		},
+		{
+			name:    "Unhealthy target uses the default unhealthy interval",
+			cfg:     config.Config{HealthCheckInterval: config.Seconds(45)},
+			healthy: false,
+			min:     defaultUnhealthyInterval,
+			max:     defaultUnhealthyInterval,
+		},
+		{
+			name: "Unhealthy target uses the configured interval",
+			cfg: config.Config{
+				HealthCheckInterval: config.Seconds(45),
+				HealthCheck:         config.HealthCheck{UnhealthyInterval: config.Seconds(2)},
+			},
+			healthy: false,
+			min:     2 * time.Second,
+			max:     2 * time.Second,
+		},
+		{
+			name: "Healthy target with jitter",
+			cfg: config.Config{
+				HealthCheckInterval: config.Seconds(40),
+				HealthCheck:         config.HealthCheck{JitterPercent: 10},
+			},
+			healthy: true,
+			min:     36 * time.Second,
+			max:     44 * time.Second,
+		},
+		{
+			name:    "Unset ticker time falls back to the default interval",
+			cfg:     config.Config{},
+			healthy: true,
+			min:     defaultInterval,
+			max:     defaultInterval,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(&tt.cfg, http.DefaultClient)
+			for i := 0; i < 100; i++ {
+				interval := checker.nextInterval(tt.healthy)
+				assert.GreaterOrEqual(t, interval, tt.min)
+				assert.LessOrEqual(t, interval, tt.max)
+			}
+		})
+	}
+}
+
+func TestJitter_CapsPercentage(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := jitter(10*time.Second, 500)
+		assert.GreaterOrEqual(t, d, 5*time.Second)
+		assert.LessOrEqual(t, d, 15*time.Second)
+	}
+}
+
+func TestProbe_Timeout(t *testing.T) {
+	cfg := &config.Config{
+		Server: config.Server{Port: "8080"},
+		Backend: config.Backend{
+			Endpoint: map[string]config.Endpoint{
+				config.HealthcheckEndpoint: {URL: "/health", Timeout: config.Seconds(1)},
+			},
+		},
+	}
+
+	// The transport never releases, so only the per-probe timeout can end the request
+	transport := &blockingRoundTripper{release: make(chan struct{})}
+	checker := NewChecker(cfg, &http.Client{Transport: transport})
+
+	start := time.Now()
+	_, err := checker.probeWithin(context.Background(), "http://localhost:8080/health", 0)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
+
+func TestUpdate_KeepsStateOfUnchangedTargets(t *testing.T) {
+	cfg := &config.Config{
+		Server:  config.Server{Port: "8080"},
+		Backend: config.Backend{Routes: config.Routes("8081", "8082", "8083", "8084")},
+	}
+	checker := NewChecker(cfg, http.DefaultClient)
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	removed := checker.targets[3]
+	checker.record(checker.targets[1], result{checkedAt: time.Now(), err: errors.New("connection refused")})
+	checker.record(checker.targets[2], result{checkedAt: time.Now()})
+	checker.record(checker.targets[4], result{checkedAt: time.Now()})
+
+	checker.Update(&config.Config{
+		Server: config.Server{Port: "8080"},
+		Backend: config.Backend{Routes: []config.Route{
+			{URL: "8081"},               // Unchanged
+			{URL: "8082", Zone: "eu-1"}, // New metadata, same probe
+			{URL: "8085"},               // New
+		}},
+		HealthCheck: config.HealthCheck{Probes: map[string]config.Probe{
+			"8084": {Type: "exec", Command: "true"}, // Not a route anymore
+		}},
+	})
+
+	assert.Equal(t, StateUnhealthy, checker.Status("8081").State)
+	assert.Equal(t, StateHealthy, checker.Status("8082").State)
+	assert.Equal(t, "eu-1", checker.Status("8082").Zone)
+	assert.Equal(t, StateUnknown, checker.Status("8085").State)
+
+	names := make([]string, 0, 3)
+	for _, backend := range checker.Backends() {
+		names = append(names, backend.Name)
+	}
+	assert.Equal(t, []string{"8081", "8082", "8085"}, names)
+
+	// Probes of removed targets that finish after the update are ignored
+	checker.record(removed, result{checkedAt: time.Now()})
+	assert.Equal(t, StateUnknown, checker.Status("8083").State)
+	assert.Empty(t, checker.History()["8083"])
+}
+
+func TestUpdate_ProbesNewTargetsWhileRunning(t *testing.T) {
+	cfg := &config.Config{
+		Server:              config.Server{Port: "8080"},
+		Backend:             config.Backend{Routes: config.Routes("8081")},
+		HealthCheckInterval: config.Seconds(60),
+	}
+
+	var mu sync.Mutex
+	probed := make(map[string]int)
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		probed[req.URL.Host]++
+		mu.Unlock()
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString("OK"))}, nil
+	})
+	checker := NewChecker(cfg, &http.Client{Transport: transport})
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checker.Run(ctx)
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	checker.Update(&config.Config{
+		Server:              config.Server{Port: "8080"},
+		Backend:             config.Backend{Routes: config.Routes("8081", "8082")},
+		HealthCheckInterval: config.Seconds(60),
+	})
+	time.Sleep(100 * time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after the context was canceled")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string]int{"localhost:8080": 1, "localhost:8081": 1, "localhost:8082": 1}, probed,
+		"the new target is probed right away, unchanged ones keep their schedule")
+	assert.Equal(t, StateHealthy, checker.Status("8082").State)
+}
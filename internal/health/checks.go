@@ -0,0 +1,44 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DiskSpaceCheck returns a check that fails when the filesystem holding path has less than minFreeBytes available.
+func DiskSpaceCheck(path string, minFreeBytes uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		free, err := freeDiskSpace(path)
+		if err != nil {
+			return err
+		}
+		if free < minFreeBytes {
+			return fmt.Errorf("%d bytes free on %s, need at least %d", free, path, minFreeBytes)
+		}
+		return nil
+	}
+}
+
+// HTTPCheck returns a check that fails unless a GET request to url answers with a status below 400.
+func HTTPCheck(client *http.Client, url string) CheckFunc {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd)
+
+package health
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// freeDiskSpace is not supported on this platform.
+func freeDiskSpace(path string) (uint64, error) {
+	return 0, fmt.Errorf("disk space checks are not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+)
+
+const (
+	maxExecOutputBytes = 4 << 10         // Upper bound for the stdout/stderr kept from an exec probe
+	execWaitDelay      = 2 * time.Second // Time allowed for output pipes to close after the command is killed
+)
+
+// execProbe runs the configured command and reports an error unless it exits with code 0.
+// It returns the combined stdout and stderr of the command, truncated to maxExecOutputBytes.
+func (c *Checker) execProbe(ctx context.Context, probe *config.Probe) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.probeTimeout(probe))
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, probe.Command, probe.Args...)
+	cmd.Env = append(os.Environ(), envList(probe.Env)...)
+	cmd.WaitDelay = execWaitDelay
+
+	output := &limitedBuffer{limit: maxExecOutputBytes}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("command timed out: %v", ctx.Err())
+	}
+	return output.String(), err
+}
+
+// probeTimeout returns the timeout of a single probe, falling back to the healthcheck endpoint timeout.
+// Exec probes always get a deadline so a hanging command cannot hold a worker forever.
+func (c *Checker) probeTimeout(probe *config.Probe) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	timeout := c.cfg.Backend.Endpoint[config.HealthcheckEndpoint].Timeout.Duration()
+	if probe != nil && probe.Timeout > 0 {
+		timeout = probe.Timeout.Duration()
+	}
+	if timeout <= 0 {
+		timeout = c.interval
+	}
+	return timeout
+}
+
+// commandLine renders a probe command for logs.
+func commandLine(probe *config.Probe) string {
+	return strings.Join(append([]string{probe.Command}, probe.Args...), " ")
+}
+
+// envList converts an environment map to KEY=VALUE pairs in a stable order.
+func envList(env map[string]string) []string {
+	list := make([]string, 0, len(env))
+	for key, value := range env {
+		list = append(list, key+"="+value)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// limitedBuffer is a concurrency-safe writer that keeps only the first limit bytes written to it.
+type limitedBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.truncated {
+		return b.buf.String() + "...(truncated)"
+	}
+	return b.buf.String()
+}
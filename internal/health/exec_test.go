@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecProbe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("exec probe tests rely on sh")
+	}
+
+	tests := []struct {
+		name           string
+		probe          config.Probe
+		expectedError  string
+		expectedOutput string
+	}{
+		{
+			name:           "Exit code 0 is healthy",
+			probe:          config.Probe{Type: "exec", Command: "sh", Args: []string{"-c", "echo pong"}},
+			expectedOutput: "pong\n",
+		},
+		{
+			name:           "Non-zero exit code is unhealthy",
+			probe:          config.Probe{Type: "exec", Command: "sh", Args: []string{"-c", "echo refused >&2; exit 3"}},
+			expectedError:  "exit status 3",
+			expectedOutput: "refused\n",
+		},
+		{
+			name: "Configured environment is passed",
+			probe: config.Probe{
+				Type:    "exec",
+				Command: "sh",
+				Args:    []string{"-c", `echo "$LEGACY_PORT"`},
+				Env:     map[string]string{"LEGACY_PORT": "8083"},
+			},
+			expectedOutput: "8083\n",
+		},
+		{
+			name:          "Command exceeding its timeout is unhealthy",
+			probe:         config.Probe{Type: "exec", Command: "sleep", Args: []string{"5"}, Timeout: config.Seconds(1)},
+			expectedError: "command timed out",
+		},
+		{
+			name:          "Missing command is unhealthy",
+			probe:         config.Probe{Type: "exec", Command: "/does/not/exist"},
+			expectedError: "no such file or directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(&config.Config{}, http.DefaultClient)
+
+			start := time.Now()
+			output, err := checker.execProbe(context.Background(), &tt.probe)
+
+			assert.Less(t, time.Since(start), 4*time.Second)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.expectedError)
+			}
+			assert.Equal(t, tt.expectedOutput, output)
+		})
+	}
+}
+
+func TestExecProbe_OutputIsKeptInHistory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("exec probe tests rely on sh")
+	}
+
+	cfg := &config.Config{
+		Server:  config.Server{Port: "8080"},
+		Backend: config.Backend{Routes: config.Routes("8081", "8083")},
+		HealthCheck: config.HealthCheck{
+			Probes: map[string]config.Probe{
+				"8083": {Type: "exec", Command: "sh", Args: []string{"-c", "echo legacy down; exit 1"}},
+			},
+		},
+	}
+	checker := NewChecker(cfg, http.DefaultClient)
+
+	// Only the configured backend is probed with the command
+	assert.Equal(t, "http://localhost:8081/health", checker.targets[1].String())
+	assert.Equal(t, `exec sh -c echo legacy down; exit 1`, checker.targets[2].String())
+
+	assert.False(t, checker.check(context.Background(), checker.targets[2]))
+
+	events := checker.History()["8083"]
+	assert.Len(t, events, 1)
+	assert.Equal(t, StateUnhealthy, events[0].To)
+	assert.Equal(t, "exit status 1", events[0].Reason)
+	assert.Equal(t, "legacy down\n", events[0].Output)
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	buf := &limitedBuffer{limit: 4}
+
+	n, err := buf.Write([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = buf.Write([]byte("defgh"))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	assert.True(t, strings.HasPrefix(buf.String(), "abcd"))
+	assert.True(t, strings.HasSuffix(buf.String(), "(truncated)"))
+}
@@ -1,18 +1,19 @@
 package health
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
 	"sync"
-	"time"
-
-	"github.com/samargupta114/Roundrobinator.git/internal/config"
 )
 
 // HealthResponse is the structure for the health check response
+// Backends may report their load and ask to be drained, so the balancer can take them into account.
 type HealthResponse struct {
-	Status string `json:"status"`
+	Status string        `json:"status"`           // "OK", "DEGRADED", "DRAINING" or "FAIL"
+	Load   *float64      `json:"load,omitempty"`   // Current load between 0 (idle) and 1 (saturated)
+	Checks []CheckResult `json:"checks,omitempty"` // Dependency check results of the Application API
 }
 
 // HealthCheckHandler handles the /health endpoint for checking the health of the server
@@ -34,50 +35,18 @@ func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Health check response : " + response.Status)
 }
 
-// StartHealthCheck performs periodic health checks for backend services
-func StartHealthCheck(cfg *config.Config, wg *sync.WaitGroup) {
+// StartHealthCheck performs periodic health checks for backend services until ctx is canceled.
+func StartHealthCheck(ctx context.Context, checker *Checker, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	log.Printf("Starting health check for backend servers")
 
-	// Set up a ticker to run health checks periodically
-	ticker := time.NewTicker(time.Duration(cfg.HealthCheckTickerTimeInSeconds) * time.Second)
-	defer ticker.Stop()
-
-	// Run health checks until the context is canceled
-	for {
-		select {
-		case <-ticker.C:
-			// Perform health checks for the configured services using the injected function
-			checkHealth(cfg)
-		}
-	}
-}
+	// Run the checker until the context is canceled
+	checker.Run(ctx)
 
-// checkHealth checks the health of the application and round-robin API servers.
-func checkHealth(cfg *config.Config) {
-	// Define health check URLs based on configuration
-	healthURLs := []string{
-		"http://localhost:" + cfg.Server.Port + "/health", // Round Robin API
+	if err := checker.Close(); err != nil {
+		log.Printf("Failed to close health checker: %v", err)
 	}
 
-	// Add all application API URLs from config
-	for _, port := range cfg.Backend.Routes {
-		healthURLs = append(healthURLs, "http://localhost:"+port+"/health")
-	}
-
-	// Iterate through all health check URLs and log the results
-	for _, url := range healthURLs {
-		resp, err := http.Get(url)
-		if err != nil || resp.StatusCode != http.StatusOK {
-			//push alerts
-			log.Printf("Health check failed for %s: %v\n", url, err)
-		} else {
-			log.Printf("Health check succeeded for %s: %d\n", url, resp.StatusCode)
-			err := resp.Body.Close() // Close the response body to avoid resource leakage
-			if err != nil {
-				log.Printf("Cannot Close response Body , Error:  %v\n", err)
-			}
-		}
-	}
+	log.Printf("Stopped health check for backend servers")
 }
@@ -0,0 +1,155 @@
+package health
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event records a single health state transition of a backend.
+type Event struct {
+	Time      time.Time `json:"time"`             // When the probe that caused the transition started
+	Backend   string    `json:"backend"`          // Backend route
+	From      string    `json:"from"`             // State before the transition
+	To        string    `json:"to"`               // State after the transition
+	Reason    string    `json:"reason,omitempty"` // Why the probe failed, if it did
+	LatencyMs float64   `json:"latency_ms"`       // Duration of the probe in milliseconds
+	Output    string    `json:"output,omitempty"` // Captured stdout/stderr of exec probes
+}
+
+// History keeps a bounded, per-backend log of health transitions in memory,
+// optionally appending every event to a JSONL file.
+type History struct {
+	mu     sync.RWMutex
+	size   int                // Maximum number of events kept per backend
+	events map[string][]Event // Oldest first, keyed by backend
+	file   *os.File           // Append-only persistence; nil when disabled
+}
+
+// NewHistory creates an in-memory History keeping up to size events per backend.
+func NewHistory(size int) *History {
+	return &History{
+		size:   size,
+		events: make(map[string][]Event),
+	}
+}
+
+// Persist loads the events already stored in the JSONL file at path and appends every future event to it.
+// Lines that cannot be decoded, such as one cut short by a crash while it was written, are skipped with a warning.
+func (h *History) Persist(path string) error {
+	unterminated, err := h.load(path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open health history file: %v", err)
+	}
+	// Start the next event on a line of its own, rather than after a truncated one
+	if unterminated {
+		if _, err := file.Write([]byte{'\n'}); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write health history file: %v", err)
+		}
+	}
+
+	h.mu.Lock()
+	h.file = file
+	h.mu.Unlock()
+	return nil
+}
+
+// load reads the events of an existing history file, keeping the newest ones of every backend.
+// It reports whether the last line of the file lacks its newline.
+func (h *History) load(path string) (unterminated bool, err error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, nil // Nothing recorded yet
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open health history file: %v", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return false, fmt.Errorf("failed to read health history file: %v", err)
+		}
+		unterminated = err == io.EOF && len(data) > 0
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+			var event Event
+			if decodeErr := json.Unmarshal(trimmed, &event); decodeErr != nil {
+				log.Printf("Skipping line %d of health history file %s: %v", line, path, decodeErr)
+			} else {
+				h.mu.Lock()
+				h.append(event)
+				h.mu.Unlock()
+			}
+		}
+		if err == io.EOF {
+			return unterminated, nil
+		}
+	}
+}
+
+// Add records an event in memory and, when persistence is enabled, in the history file.
+// The event is appended to both under one lock, so the file keeps the order of the in-memory log.
+func (h *History) Add(event Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.append(event)
+	if h.file == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = h.file.Write(append(line, '\n'))
+	return err
+}
+
+// append adds an event to the in-memory log, dropping the oldest event of the backend once it is full. h.mu must be held.
+func (h *History) append(event Event) {
+	events := append(h.events[event.Backend], event)
+	if len(events) > h.size {
+		events = events[len(events)-h.size:]
+	}
+	h.events[event.Backend] = events
+}
+
+// Events returns a copy of the recorded events of every backend, oldest first.
+func (h *History) Events() map[string][]Event {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	events := make(map[string][]Event, len(h.events))
+	for backend, backendEvents := range h.events {
+		events[backend] = append([]Event(nil), backendEvents...)
+	}
+	return events
+}
+
+// Close stops persisting events and closes the history file.
+func (h *History) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.file == nil {
+		return nil
+	}
+	err := h.file.Close()
+	h.file = nil
+	return err
+}
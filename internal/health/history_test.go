@@ -0,0 +1,176 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory_KeepsNewestEventsPerBackend(t *testing.T) {
+	history := NewHistory(2)
+
+	for i, to := range []string{StateHealthy, StateUnhealthy, StateHealthy} {
+		assert.NoError(t, history.Add(Event{Time: time.Unix(int64(i), 0), Backend: "8081", To: to}))
+	}
+	assert.NoError(t, history.Add(Event{Backend: "8082", To: StateHealthy}))
+
+	events := history.Events()
+	assert.Len(t, events["8081"], 2)
+	assert.Equal(t, StateUnhealthy, events["8081"][0].To)
+	assert.Equal(t, StateHealthy, events["8081"][1].To)
+	assert.Len(t, events["8082"], 1)
+}
+
+func TestHistory_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health-history.jsonl")
+
+	// First run records two transitions
+	history := NewHistory(10)
+	assert.NoError(t, history.Persist(path))
+	assert.NoError(t, history.Add(Event{Backend: "8081", From: StateUnknown, To: StateHealthy}))
+	assert.NoError(t, history.Add(Event{Backend: "8081", From: StateHealthy, To: StateUnhealthy, Reason: "connection refused"}))
+	assert.NoError(t, history.Close())
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(content)), "\n"), 2)
+
+	// A restart loads them back and keeps appending
+	restarted := NewHistory(10)
+	assert.NoError(t, restarted.Persist(path))
+	assert.NoError(t, restarted.Add(Event{Backend: "8081", From: StateUnhealthy, To: StateHealthy}))
+	assert.NoError(t, restarted.Close())
+
+	events := restarted.Events()["8081"]
+	assert.Len(t, events, 3)
+	assert.Equal(t, "connection refused", events[1].Reason)
+
+	content, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(content)), "\n"), 3)
+}
+
+func TestHistory_PersistsInMemoryOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health-history.jsonl")
+	history := NewHistory(100)
+	assert.NoError(t, history.Persist(path))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, history.Add(Event{Backend: "8081", Reason: strconv.Itoa(i)}))
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, history.Close())
+
+	// A restart replays the events in the order they were served
+	restarted := NewHistory(100)
+	assert.NoError(t, restarted.Persist(path))
+	assert.NoError(t, restarted.Close())
+	assert.Equal(t, history.Events(), restarted.Events())
+}
+
+func TestHistory_PersistSkipsCorruptLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health-history.jsonl")
+	content := "{\"backend\":\"8081\",\"to\":\"healthy\"}\nnot json\n{\"backend\":\"8081\",\"to\":\"unhealthy\"}\n{\"backend\":\"80"
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	// The line in the middle and the one truncated by a crash are skipped
+	history := NewHistory(10)
+	assert.NoError(t, history.Persist(path))
+	events := history.Events()["8081"]
+	assert.Len(t, events, 2)
+	assert.Equal(t, StateUnhealthy, events[1].To)
+
+	// The next event starts a line of its own, so it survives the following restart
+	assert.NoError(t, history.Add(Event{Backend: "8081", From: StateUnhealthy, To: StateHealthy}))
+	assert.NoError(t, history.Close())
+
+	restarted := NewHistory(10)
+	assert.NoError(t, restarted.Persist(path))
+	assert.NoError(t, restarted.Close())
+	events = restarted.Events()["8081"]
+	assert.Len(t, events, 3)
+	assert.Equal(t, StateHealthy, events[2].To)
+}
+
+func TestChecker_RecordsTransitions(t *testing.T) {
+	cfg := &config.Config{
+		Server:  config.Server{Port: "8080"},
+		Backend: config.Backend{Routes: config.Routes("8081")},
+	}
+	checker := NewChecker(cfg, http.DefaultClient)
+	backend := checker.targets[1]
+
+	// Repeated outcomes in the same state are not transitions
+	checker.record(backend, result{checkedAt: time.Now(), latency: time.Millisecond})
+	checker.record(backend, result{checkedAt: time.Now(), latency: time.Millisecond})
+	checker.record(backend, result{checkedAt: time.Now(), latency: 3 * time.Millisecond, err: errors.New("connection refused")})
+	checker.record(backend, result{checkedAt: time.Now(), latency: time.Millisecond})
+
+	// The Round Robin API itself is not a backend
+	checker.record(checker.targets[0], result{checkedAt: time.Now(), latency: time.Millisecond})
+
+	history := checker.History()
+	assert.Len(t, history, 1)
+
+	events := history["8081"]
+	assert.Len(t, events, 3)
+	assert.Equal(t, StateUnknown, events[0].From)
+	assert.Equal(t, StateHealthy, events[0].To)
+	assert.Equal(t, StateUnhealthy, events[1].To)
+	assert.Equal(t, "connection refused", events[1].Reason)
+	assert.Equal(t, 3.0, events[1].LatencyMs)
+	assert.Equal(t, StateHealthy, events[2].To)
+}
+
+func TestHistoryHandler(t *testing.T) {
+	cfg := &config.Config{
+		Server:  config.Server{Port: "8080"},
+		Backend: config.Backend{Routes: config.Routes("8081", "8082")},
+	}
+	checker := NewChecker(cfg, http.DefaultClient)
+	for _, target := range checker.targets {
+		checker.record(target, result{checkedAt: time.Now(), latency: time.Millisecond})
+	}
+
+	tests := []struct {
+		name             string
+		query            string
+		expectedBackends []string
+	}{
+		{name: "All backends", query: "", expectedBackends: []string{"8081", "8082"}},
+		{name: "Single backend", query: "?backend=8082", expectedBackends: []string{"8082"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/health/history"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			checker.HistoryHandler(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response HistoryResponse
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			assert.Len(t, response.Backends, len(tt.expectedBackends))
+			for _, backend := range tt.expectedBackends {
+				assert.Len(t, response.Backends[backend], 1)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package health
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+const (
+	statusOK          = "OK"          // Reported when the service is alive or ready
+	statusUnavailable = "UNAVAILABLE" // Reported when too few backends are healthy
+)
+
+// ReadinessResponse is the structure for the /readyz response.
+type ReadinessResponse struct {
+	Status             string         `json:"status"`
+	HealthyBackends    int            `json:"healthy_backends"`
+	TotalBackends      int            `json:"total_backends"`
+	MinHealthyBackends int            `json:"min_healthy_backends"`
+	PanicMode          bool           `json:"panic_mode"`         // Whether the balancer ignores health because too few backends are healthy
+	Backends           []TargetStatus `json:"backends,omitempty"` // Only included for verbose requests
+}
+
+// LivenessHandler handles the /livez endpoint. The process is alive as long as it can answer.
+func LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, HealthResponse{Status: statusOK})
+}
+
+// ReadinessHandler handles the /readyz endpoint.
+// It answers 503 while fewer than the configured minimum of backends are healthy,
+// and lists the state of every backend when called with ?verbose=true.
+func (c *Checker) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	backends := c.Backends()
+
+	response := ReadinessResponse{
+		Status:             statusOK,
+		TotalBackends:      len(backends),
+		MinHealthyBackends: max(c.config().HealthCheck.MinHealthyBackends, 1),
+	}
+	for _, backend := range backends {
+		if backend.State == StateHealthy {
+			response.HealthyBackends++
+		}
+	}
+
+	c.mu.RLock()
+	panicMode := c.panicMode
+	c.mu.RUnlock()
+	if panicMode != nil {
+		response.PanicMode = panicMode()
+	}
+
+	statusCode := http.StatusOK
+	if response.HealthyBackends < response.MinHealthyBackends {
+		//push alerts
+		response.Status = statusUnavailable
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	if verbose, _ := strconv.ParseBool(r.URL.Query().Get("verbose")); verbose {
+		response.Backends = backends
+	}
+
+	writeJSON(w, statusCode, response)
+}
+
+// HistoryResponse is the structure for the health history admin endpoint.
+type HistoryResponse struct {
+	Backends map[string][]Event `json:"backends"` // Transitions per backend, oldest first
+}
+
+// HistoryHandler handles the health history admin endpoint.
+// It lists the recorded transitions of every backend, or of a single one with ?backend=<route>.
+func (c *Checker) HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	events := c.History()
+
+	if backend := r.URL.Query().Get("backend"); backend != "" {
+		events = map[string][]Event{backend: events[backend]}
+	}
+
+	writeJSON(w, http.StatusOK, HistoryResponse{Backends: events})
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		// The status line is already sent, so the failure can only be logged
+		log.Printf("Failed to encode health response: %v", err)
+	}
+}
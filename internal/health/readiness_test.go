@@ -0,0 +1,116 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLivenessHandler(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/livez", nil)
+	rr := httptest.NewRecorder()
+
+	LivenessHandler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var response HealthResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Equal(t, "OK", response.Status)
+}
+
+func TestReadinessHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		minHealthy         int
+		probeErrors        map[string]error // Backends missing from the map are never probed
+		query              string
+		expectedStatusCode int
+		expectedStatus     string
+		expectedHealthy    int
+		expectedBackends   int
+	}{
+		{
+			name:               "No backend probed yet",
+			probeErrors:        map[string]error{},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     "UNAVAILABLE",
+			expectedHealthy:    0,
+		},
+		{
+			name:               "One healthy backend meets the default minimum",
+			probeErrors:        map[string]error{"8081": nil, "8082": errors.New("connection refused")},
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     "OK",
+			expectedHealthy:    1,
+		},
+		{
+			name:               "Below the configured minimum",
+			minHealthy:         2,
+			probeErrors:        map[string]error{"8081": nil, "8082": errors.New("connection refused")},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     "UNAVAILABLE",
+			expectedHealthy:    1,
+		},
+		{
+			name:               "Verbose lists every backend",
+			minHealthy:         2,
+			probeErrors:        map[string]error{"8081": nil, "8082": nil},
+			query:              "?verbose=true",
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     "OK",
+			expectedHealthy:    2,
+			expectedBackends:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Server:      config.Server{Port: "8080"},
+				Backend:     config.Backend{Routes: config.Routes("8081", "8082")},
+				HealthCheck: config.HealthCheck{MinHealthyBackends: tt.minHealthy},
+			}
+			checker := NewChecker(cfg, http.DefaultClient)
+			for _, target := range checker.targets {
+				if err, probed := tt.probeErrors[target.name]; probed {
+					checker.record(target, result{checkedAt: time.Now(), latency: 5 * time.Millisecond, err: err})
+				}
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/readyz"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			checker.ReadinessHandler(rr, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rr.Code)
+
+			var response ReadinessResponse
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			assert.Equal(t, tt.expectedStatus, response.Status)
+			assert.Equal(t, tt.expectedHealthy, response.HealthyBackends)
+			assert.Equal(t, 2, response.TotalBackends)
+			assert.Len(t, response.Backends, tt.expectedBackends)
+			assert.False(t, response.PanicMode)
+		})
+	}
+}
+
+func TestReadinessHandler_ReportsPanicMode(t *testing.T) {
+	checker := NewChecker(&config.Config{Backend: config.Backend{Routes: config.Routes("8081")}}, http.DefaultClient)
+	checker.ReportPanicMode(func() bool { return true })
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rr := httptest.NewRecorder()
+	checker.ReadinessHandler(rr, req)
+
+	var response ReadinessResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.True(t, response.PanicMode)
+}
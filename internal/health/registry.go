@@ -0,0 +1,210 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+)
+
+const (
+	statusDegraded = "DEGRADED" // Reported when only non-critical checks fail
+	statusFail     = "FAIL"     // Reported when a critical check fails
+	statusDraining = "DRAINING" // Reported when the server asks for no new traffic
+
+	defaultCheckTimeout = 5 * time.Second // Timeout of a dependency check when none is configured
+)
+
+// CheckFunc checks a single dependency. A non-nil error marks the check as failed.
+type CheckFunc func(ctx context.Context) error
+
+// Check is a named dependency check run by a Registry.
+type Check struct {
+	Name     string        // Identifies the check in health responses
+	Timeout  time.Duration // Upper bound for a single run; 0 falls back to the default timeout
+	Critical bool          // A failing critical check makes the server unhealthy rather than degraded
+	Func     CheckFunc     // The check itself
+}
+
+// CheckResult is the outcome of a single dependency check.
+type CheckResult struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"` // "OK" or "FAIL"
+	Critical   bool    `json:"critical"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// Registry holds the dependency checks reported by the Application API /health endpoint,
+// along with the load and drain intent the server reports to the balancer.
+type Registry struct {
+	mu       sync.RWMutex
+	checks   []Check
+	load     func() float64 // Reports the current load; nil when not reported
+	draining bool           // Whether the server asks for no new traffic
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// RegistryFromConfig creates a Registry with the configured dependency checks.
+func RegistryFromConfig(checks []config.DependencyCheck) (*Registry, error) {
+	registry := NewRegistry()
+	for _, dc := range checks {
+		var fn CheckFunc
+		switch dc.Type {
+		case "disk":
+			fn = DiskSpaceCheck(dc.Path, dc.MinFreeBytes)
+		case "http":
+			fn = HTTPCheck(http.DefaultClient, dc.URL)
+		default:
+			return nil, fmt.Errorf("unknown type %q for dependency check %q", dc.Type, dc.Name)
+		}
+
+		check := Check{
+			Name:     dc.Name,
+			Timeout:  dc.Timeout.Duration(),
+			Critical: dc.Critical,
+			Func:     fn,
+		}
+		if err := registry.Register(check); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// Register adds a named check to the registry. Check names must be unique.
+func (reg *Registry) Register(check Check) error {
+	if check.Name == "" || check.Func == nil {
+		return fmt.Errorf("dependency check needs a name and a function")
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for _, existing := range reg.checks {
+		if existing.Name == check.Name {
+			return fmt.Errorf("dependency check %q is already registered", check.Name)
+		}
+	}
+	reg.checks = append(reg.checks, check)
+	return nil
+}
+
+// ReportLoad makes health responses include the load returned by load, between 0 (idle) and 1 (saturated).
+func (reg *Registry) ReportLoad(load func() float64) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.load = load
+}
+
+// SetDraining makes health responses ask the balancer to stop sending new traffic to this server.
+func (reg *Registry) SetDraining(draining bool) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.draining = draining
+}
+
+// Run executes every registered check concurrently and aggregates the results.
+// The overall status is "FAIL" when a critical check fails, "DRAINING" when the server is draining,
+// "DEGRADED" when only non-critical checks fail and "OK" otherwise.
+func (reg *Registry) Run(ctx context.Context) HealthResponse {
+	reg.mu.RLock()
+	checks := append([]Check(nil), reg.checks...)
+	load, draining := reg.load, reg.draining
+	reg.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	response := HealthResponse{Status: statusOK, Checks: results}
+	for _, result := range results {
+		if result.Status == statusOK {
+			continue
+		}
+		if result.Critical {
+			response.Status = statusFail
+			break
+		}
+		response.Status = statusDegraded
+	}
+
+	if draining && response.Status != statusFail {
+		response.Status = statusDraining
+	}
+	if load != nil {
+		value := load()
+		response.Load = &value
+	}
+	return response
+}
+
+// runCheck runs a single check within its timeout.
+func runCheck(ctx context.Context, check Check) CheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := CheckResult{Name: check.Name, Status: statusOK, Critical: check.Critical}
+
+	// Run the check in its own goroutine so one that ignores its context cannot outlive the timeout
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() { errCh <- check.Func(ctx) }()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+
+	if err != nil {
+		result.Status = statusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Handler handles the Application API /health endpoint.
+// It reports every check result and answers 503 when a critical check fails.
+func (reg *Registry) Handler(w http.ResponseWriter, r *http.Request) {
+	response := reg.Run(r.Context())
+
+	statusCode := http.StatusOK
+	if response.Status == statusFail {
+		//push alerts
+		statusCode = http.StatusServiceUnavailable
+	}
+	writeJSON(w, statusCode, response)
+}
+
+// failedChecks returns the names of the failed checks in a health response.
+func failedChecks(response HealthResponse) string {
+	var names []string
+	for _, result := range response.Checks {
+		if result.Status != statusOK {
+			names = append(names, result.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,212 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func passing(ctx context.Context) error { return nil }
+
+func failing(ctx context.Context) error { return errors.New("dependency down") }
+
+func TestRegistry_Handler(t *testing.T) {
+	tests := []struct {
+		name               string
+		checks             []Check
+		expectedStatusCode int
+		expectedStatus     string
+	}{
+		{
+			name:               "No checks",
+			checks:             nil,
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     "OK",
+		},
+		{
+			name: "All checks pass",
+			checks: []Check{
+				{Name: "db", Critical: true, Func: passing},
+				{Name: "cache", Func: passing},
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     "OK",
+		},
+		{
+			name: "Non-critical check fails",
+			checks: []Check{
+				{Name: "db", Critical: true, Func: passing},
+				{Name: "cache", Func: failing},
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedStatus:     "DEGRADED",
+		},
+		{
+			name: "Critical check fails",
+			checks: []Check{
+				{Name: "db", Critical: true, Func: failing},
+				{Name: "cache", Func: failing},
+			},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedStatus:     "FAIL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			for _, check := range tt.checks {
+				assert.NoError(t, registry.Register(check))
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/health", nil)
+			rr := httptest.NewRecorder()
+			registry.Handler(rr, req)
+
+			assert.Equal(t, tt.expectedStatusCode, rr.Code)
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+			var response HealthResponse
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			assert.Equal(t, tt.expectedStatus, response.Status)
+			assert.Len(t, response.Checks, len(tt.checks))
+			for i, check := range tt.checks {
+				assert.Equal(t, check.Name, response.Checks[i].Name)
+				assert.Equal(t, check.Critical, response.Checks[i].Critical)
+			}
+		})
+	}
+}
+
+func TestRegistry_CheckTimeout(t *testing.T) {
+	registry := NewRegistry()
+	assert.NoError(t, registry.Register(Check{
+		Name:     "slow",
+		Timeout:  50 * time.Millisecond,
+		Critical: true,
+		Func: func(ctx context.Context) error {
+			time.Sleep(time.Second) // Ignores its context on purpose
+			return nil
+		},
+	}))
+
+	start := time.Now()
+	response := registry.Run(context.Background())
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, "FAIL", response.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), response.Checks[0].Error)
+}
+
+func TestRegistry_RegisterRejectsDuplicates(t *testing.T) {
+	registry := NewRegistry()
+	assert.NoError(t, registry.Register(Check{Name: "db", Func: passing}))
+	assert.Error(t, registry.Register(Check{Name: "db", Func: passing}))
+	assert.Error(t, registry.Register(Check{Name: "", Func: passing}))
+	assert.Error(t, registry.Register(Check{Name: "nil func"}))
+}
+
+func TestRegistry_ReportsLoadAndDraining(t *testing.T) {
+	registry := NewRegistry()
+	assert.NoError(t, registry.Register(Check{Name: "cache", Func: failing}))
+
+	response := registry.Run(context.Background())
+	assert.Equal(t, "DEGRADED", response.Status)
+	assert.Nil(t, response.Load)
+
+	registry.ReportLoad(func() float64 { return 0.8 })
+	registry.SetDraining(true)
+
+	response = registry.Run(context.Background())
+	assert.Equal(t, "DRAINING", response.Status)
+	assert.Equal(t, 0.8, *response.Load)
+
+	// A critical failure still wins over draining
+	assert.NoError(t, registry.Register(Check{Name: "db", Critical: true, Func: failing}))
+	assert.Equal(t, "FAIL", registry.Run(context.Background()).Status)
+}
+
+func TestRegistryFromConfig(t *testing.T) {
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer downstream.Close()
+
+	registry, err := RegistryFromConfig([]config.DependencyCheck{
+		{Name: "disk", Type: "disk", Path: t.TempDir(), MinFreeBytes: 1, Critical: true},
+		{Name: "downstream", Type: "http", URL: downstream.URL, Timeout: config.Seconds(1)},
+	})
+	assert.NoError(t, err)
+
+	response := registry.Run(context.Background())
+	assert.Equal(t, "DEGRADED", response.Status)
+	assert.Equal(t, "OK", response.Checks[0].Status)
+	assert.Equal(t, "FAIL", response.Checks[1].Status)
+	assert.Contains(t, response.Checks[1].Error, "unexpected status code 500")
+
+	_, err = RegistryFromConfig([]config.DependencyCheck{{Name: "unknown", Type: "ftp"}})
+	assert.Error(t, err)
+}
+
+func TestDiskSpaceCheck(t *testing.T) {
+	assert.NoError(t, DiskSpaceCheck(t.TempDir(), 1)(context.Background()))
+	assert.Error(t, DiskSpaceCheck(t.TempDir(), ^uint64(0))(context.Background()))
+	assert.Error(t, DiskSpaceCheck("/does/not/exist", 1)(context.Background()))
+}
+
+func TestProbe_UnderstandsDependencyChecks(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		body          HealthResponse
+		expectedError string
+	}{
+		{
+			name:       "Degraded backend stays healthy",
+			statusCode: http.StatusOK,
+			body:       HealthResponse{Status: "DEGRADED", Checks: []CheckResult{{Name: "cache", Status: "FAIL"}}},
+		},
+		{
+			name:       "Draining backend with reported load stays healthy",
+			statusCode: http.StatusOK,
+			body:       HealthResponse{Status: "DRAINING"},
+		},
+		{
+			name:          "Failing critical check",
+			statusCode:    http.StatusServiceUnavailable,
+			body:          HealthResponse{Status: "FAIL", Checks: []CheckResult{{Name: "db", Status: "FAIL", Critical: true}}},
+			expectedError: "unexpected status code 503 (failed checks: db)",
+		},
+		{
+			name:          "Failure reported with a 200 status",
+			statusCode:    http.StatusOK,
+			body:          HealthResponse{Status: "FAIL", Checks: []CheckResult{{Name: "db", Status: "FAIL", Critical: true}}},
+			expectedError: "reported status FAIL (failed checks: db)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, tt.statusCode, tt.body)
+			}))
+			defer backend.Close()
+
+			checker := NewChecker(&config.Config{}, http.DefaultClient)
+			_, err := checker.probeWithin(context.Background(), backend.URL, 0)
+
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedError)
+			}
+		})
+	}
+}
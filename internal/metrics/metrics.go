@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Default is the registry served on the Round Robin API /metrics endpoint.
+var Default = NewRegistry()
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	value atomic.Uint64
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Value returns the current value of the counter.
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set replaces the value of the gauge.
+func (g *Gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+// family groups the series of one metric name.
+type family struct {
+	name   string
+	help   string
+	kind   string                 // "counter" or "gauge"
+	series map[string]interface{} // *Counter or *Gauge, keyed by rendered label set
+}
+
+// Registry holds metrics and renders them in the Prometheus text exposition format.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Counter returns the counter with the given name and label pairs, creating it on first use.
+// labels alternate between label names and values.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return r.series(name, help, "counter", labels, func() interface{} { return &Counter{} }).(*Counter)
+}
+
+// Gauge returns the gauge with the given name and label pairs, creating it on first use.
+// labels alternate between label names and values.
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	return r.series(name, help, "gauge", labels, func() interface{} { return &Gauge{} }).(*Gauge)
+}
+
+// series returns an existing series or registers a new one created by create.
+func (r *Registry) series(name, help, kind string, labels []string, create func() interface{}) interface{} {
+	if len(labels)%2 != 0 {
+		panic(fmt.Sprintf("metrics: odd number of label arguments for %s", name))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{name: name, help: help, kind: kind, series: make(map[string]interface{})}
+		r.families[name] = f
+	}
+	if f.kind != kind {
+		panic(fmt.Sprintf("metrics: %s is already registered as a %s", name, f.kind))
+	}
+
+	key := renderLabels(labels)
+	s, ok := f.series[key]
+	if !ok {
+		s = create()
+		f.series[key] = s
+	}
+	return s
+}
+
+// renderLabels renders label pairs as a Prometheus label set, e.g. {pool="default"}.
+func renderLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i < len(labels); i += 2 {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[i+1])
+		pairs = append(pairs, fmt.Sprintf("%s=%q", labels[i], value))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Handler serves every registered metric in the Prometheus text exposition format.
+func (r *Registry) Handler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := r.families[name]
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			switch s := f.series[key].(type) {
+			case *Counter:
+				fmt.Fprintf(w, "%s%s %d\n", f.name, key, s.Value())
+			case *Gauge:
+				fmt.Fprintf(w, "%s%s %g\n", f.name, key, s.Value())
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Handler(t *testing.T) {
+	registry := NewRegistry()
+
+	registry.Counter("requests_total", "Requests served.", "pool", "a").Inc()
+	registry.Counter("requests_total", "Requests served.", "pool", "a").Inc()
+	registry.Counter("requests_total", "Requests served.", "pool", "b").Inc()
+	registry.Gauge("panic_mode", "Whether panic mode is active.").Set(1)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	registry.Handler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `# HELP panic_mode Whether panic mode is active.
+# TYPE panic_mode gauge
+panic_mode 1
+# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{pool="a"} 2
+requests_total{pool="b"} 1
+`, rr.Body.String())
+}
+
+func TestRegistry_ReturnsExistingSeries(t *testing.T) {
+	registry := NewRegistry()
+
+	assert.Same(t, registry.Gauge("g", "help", "k", "v"), registry.Gauge("g", "help", "k", "v"))
+	assert.NotSame(t, registry.Gauge("g", "help", "k", "v"), registry.Gauge("g", "help", "k", "w"))
+	assert.Panics(t, func() { registry.Counter("g", "help") })
+	assert.Panics(t, func() { registry.Counter("c", "help", "odd") })
+}
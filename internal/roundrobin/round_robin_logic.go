@@ -3,7 +3,32 @@ package roundrobin
 import (
 	"errors"
 	"log"
+	"math/rand/v2"
 	"sync"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/metrics"
+)
+
+const minLoadFactor = 0.05 // Share of its weight a fully loaded instance keeps
+
+const (
+	panicModeGaugeName   = "roundrobin_panic_mode"
+	panicModeGaugeHelp   = "Whether the balancer ignores health because too few instances are healthy."
+	panicModeEnteredName = "roundrobin_panic_mode_entered_total"
+	panicModeEnteredHelp = "Number of times the balancer entered panic mode."
+)
+
+var (
+	panicModeGauge   = metrics.Default.Gauge(panicModeGaugeName, panicModeGaugeHelp)
+	panicModeEntered = metrics.Default.Counter(panicModeEnteredName, panicModeEnteredHelp)
+)
+
+// Strategy selects how the balancer picks an instance among the eligible ones.
+type Strategy int
+
+const (
+	StrategyRoundRobin Strategy = iota // Smooth weighted round-robin
+	StrategyRandom                     // Weighted random choice
 )
 
 // RoundRobinInterface defines the methods for RoundRobin
@@ -11,22 +36,147 @@ type RoundRobinInterface interface {
 	Next() (string, error)
 }
 
-// RoundRobin struct holds the list of instances and the current index for round-robin distribution.
+// HealthFunc reports whether an instance may receive traffic.
+type HealthFunc func(instance string) bool
+
+// State is what the balancer knows about an instance from its health checks.
+type State struct {
+	Healthy  bool    // Whether the instance passed its latest health check
+	Draining bool    // Whether the instance asked for no new traffic
+	Load     float64 // Reported load between 0 (idle) and 1 (saturated)
+}
+
+// StateFunc reports the current State of an instance.
+type StateFunc func(instance string) State
+
+// Option configures optional RoundRobin behaviour.
+type Option func(*RoundRobin)
+
+// WithHealth makes the balancer skip instances that healthy reports as unhealthy.
+func WithHealth(healthy HealthFunc) Option {
+	return WithState(func(instance string) State {
+		return State{Healthy: healthy(instance)}
+	})
+}
+
+// WithState makes the balancer skip unhealthy and draining instances
+// and send less traffic to instances that report a high load.
+func WithState(state StateFunc) Option {
+	return func(rr *RoundRobin) {
+		rr.state = state
+	}
+}
+
+// WithWeights sets the relative share of traffic of each instance, keyed by instance.
+// Instances without a positive weight keep the default weight of 1.
+func WithWeights(weights map[string]int) Option {
+	return func(rr *RoundRobin) {
+		for i, instance := range rr.instances {
+			if weight := weights[instance]; weight > 0 {
+				rr.base[i] = float64(weight)
+			}
+		}
+	}
+}
+
+// WithPanicThreshold sets the percentage of healthy instances below which the balancer ignores health
+// and spreads traffic across every instance, so the few healthy ones are not overloaded.
+// A threshold of 0 disables panic mode.
+func WithPanicThreshold(percent int) Option {
+	return func(rr *RoundRobin) {
+		rr.panicThreshold = percent
+	}
+}
+
+// WithStrategy sets how the balancer picks an instance; the default is StrategyRoundRobin.
+func WithStrategy(strategy Strategy) Option {
+	return func(rr *RoundRobin) {
+		rr.strategy = strategy
+	}
+}
+
+// WithName names the pool of instances the balancer serves, in logs and as the "pool" label of its metrics.
+func WithName(name string) Option {
+	return func(rr *RoundRobin) {
+		rr.name = name
+		rr.panicGauge = metrics.Default.Gauge(panicModeGaugeName, panicModeGaugeHelp, "pool", name)
+		rr.panicEntered = metrics.Default.Counter(panicModeEnteredName, panicModeEnteredHelp, "pool", name)
+	}
+}
+
+// RoundRobin struct holds the list of instances and their position in the rotation.
+// It uses smooth weighted round-robin, so instances with equal weights are picked in plain rotation
+// while loaded instances are picked proportionally less often.
 type RoundRobin struct {
 	instances []string   // List of instances/ports to balance the load across
-	index     int        // Current index in the round-robin rotation
-	mu        sync.Mutex // Ensure thread-safety for accessing the index
+	base      []float64  // Configured weight of every instance
+	current   []float64  // Smooth weighted round-robin position of every instance
+	mu        sync.Mutex // Ensure thread-safety for accessing the rotation
+
+	state          StateFunc // Reports instance state; nil routes to every instance evenly
+	strategy       Strategy  // How an instance is picked among the eligible ones
+	panicThreshold int       // Healthy percentage below which health is ignored
+	panicMode      bool      // Whether the latest evaluation ignored health
+
+	name         string           // Name of the pool, for logs; empty for an unnamed balancer
+	panicGauge   *metrics.Gauge   // Reports panic mode
+	panicEntered *metrics.Counter // Counts entries into panic mode
 }
 
 // New creates a new instance of RoundRobin with the given list of API instances.
-func New(ports []string) *RoundRobin {
-	return &RoundRobin{
+func New(ports []string, opts ...Option) *RoundRobin {
+	rr := &RoundRobin{
 		instances: ports,
-		index:     0,
+		base:      make([]float64, len(ports)),
+		current:   make([]float64, len(ports)),
+	}
+	for i := range rr.base {
+		rr.base[i] = 1
+	}
+	rr.reset()
+	for _, opt := range opts {
+		opt(rr)
+	}
+	return rr
+}
+
+// Update atomically replaces the instances and options of the balancer, e.g. after a configuration reload.
+// Instances kept across the update keep their position in the rotation; options are applied afresh,
+// so weights and the panic threshold not given again fall back to their defaults.
+func (rr *RoundRobin) Update(ports []string, opts ...Option) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	positions := make(map[string]float64, len(rr.instances))
+	for i, instance := range rr.instances {
+		positions[instance] = rr.current[i]
+	}
+
+	rr.instances = ports
+	rr.base = make([]float64, len(ports))
+	rr.current = make([]float64, len(ports))
+	for i, instance := range ports {
+		rr.base[i] = 1
+		rr.current[i] = positions[instance]
+	}
+	rr.reset()
+	for _, opt := range opts {
+		opt(rr)
 	}
 }
 
+// reset restores the defaults of every option. The caller must hold rr.mu or own rr exclusively.
+func (rr *RoundRobin) reset() {
+	rr.state = nil
+	rr.strategy = StrategyRoundRobin
+	rr.panicThreshold = 0
+	rr.name = ""
+	rr.panicGauge = panicModeGauge
+	rr.panicEntered = panicModeEntered
+}
+
 // Next selects the next API instance in a round-robin fashion and ensures thread-safety.
+// Unhealthy and draining instances are skipped, unhealthy ones only until the balancer enters panic mode.
 func (rr *RoundRobin) Next() (string, error) {
 	rr.mu.Lock()
 	defer rr.mu.Unlock()
@@ -36,12 +186,128 @@ func (rr *RoundRobin) Next() (string, error) {
 		return "", errors.New("no instances available")
 	}
 
-	// Get the next instance in the round-robin cycle
-	instance := rr.instances[rr.index]
+	weights := rr.weights()
+	if rr.strategy == StrategyRandom {
+		return rr.random(weights)
+	}
+
+	// Every eligible instance advances by its weight; the one furthest ahead is picked and moved back by the total
+	best, total := -1, 0.0
+	for i, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		rr.current[i] += weight
+		total += weight
+		if best < 0 || rr.current[i] > rr.current[best] {
+			best = i
+		}
+	}
+	if best < 0 {
+		//push alerts
+		return "", errors.New("no healthy instances available")
+	}
+	rr.current[best] -= total
+
+	instance := rr.instances[best]
 	log.Printf("Routed the application to the instance  : %s", instance)
 
-	// Update the index for the next request
-	rr.index = (rr.index + 1) % len(rr.instances)
+	return instance, nil
+}
+
+// random picks an eligible instance at random, proportionally to its weight.
+func (rr *RoundRobin) random(weights []float64) (string, error) {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+	if total <= 0 {
+		//push alerts
+		return "", errors.New("no healthy instances available")
+	}
 
+	pick := rand.Float64() * total
+	chosen := -1
+	for i, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		chosen = i
+		if pick < weight {
+			break
+		}
+		pick -= weight
+	}
+
+	instance := rr.instances[chosen]
+	log.Printf("Routed the application to the instance  : %s", instance)
 	return instance, nil
 }
+
+// weights returns the effective weight of every instance for the next request; 0 means not eligible.
+// It also updates the panic mode state.
+func (rr *RoundRobin) weights() []float64 {
+	weights := make([]float64, len(rr.instances))
+	states := make([]State, len(rr.instances))
+	healthyCount, candidates := 0, 0
+	for i, instance := range rr.instances {
+		states[i] = State{Healthy: true}
+		if rr.state != nil {
+			states[i] = rr.state(instance)
+		}
+		if states[i].Draining {
+			continue // Draining instances take no new traffic, even in panic mode
+		}
+		candidates++
+		if states[i].Healthy {
+			healthyCount++
+		}
+	}
+
+	panicMode := rr.panicThreshold > 0 && healthyCount*100 < rr.panicThreshold*candidates
+	if panicMode != rr.panicMode {
+		rr.panicMode = panicMode
+		if panicMode {
+			//push alerts
+			log.Printf("Entering panic mode%s: %d of %d instances healthy, below the %d%% threshold; routing to all instances",
+				rr.poolSuffix(), healthyCount, candidates, rr.panicThreshold)
+			rr.panicGauge.Set(1)
+			rr.panicEntered.Inc()
+		} else {
+			log.Printf("Leaving panic mode%s: %d of %d instances healthy", rr.poolSuffix(), healthyCount, candidates)
+			rr.panicGauge.Set(0)
+		}
+	}
+
+	for i, state := range states {
+		// In panic mode every instance that is not draining is eligible regardless of health
+		if state.Draining || (!state.Healthy && !panicMode) {
+			continue
+		}
+		weights[i] = rr.base[i] * loadFactor(state.Load)
+	}
+	return weights
+}
+
+// poolSuffix names the pool in log lines of named balancers.
+func (rr *RoundRobin) poolSuffix() string {
+	if rr.name == "" {
+		return ""
+	}
+	return " for pool " + rr.name
+}
+
+// loadFactor scales an instance's weight down as its reported load goes up.
+// Fully loaded instances keep a small share so they are never starved completely.
+func loadFactor(load float64) float64 {
+	return max(1-min(max(load, 0), 1), minLoadFactor)
+}
+
+// PanicMode reports whether the balancer currently ignores health because too few instances are healthy.
+func (rr *RoundRobin) PanicMode() bool {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rr.weights() // Re-evaluate against the current health
+	return rr.panicMode
+}
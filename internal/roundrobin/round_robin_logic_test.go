@@ -4,6 +4,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/samargupta114/Roundrobinator.git/internal/metrics"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -49,3 +50,218 @@ func TestRoundRobin(t *testing.T) {
 		})
 	}
 }
+
+// TestRoundRobin_SkipsUnhealthyInstances checks that unhealthy instances are left out of the rotation.
+func TestRoundRobin_SkipsUnhealthyInstances(t *testing.T) {
+	tests := []struct {
+		name           string
+		unhealthy      map[string]bool
+		panicThreshold int
+		expectedInst   []string
+		expectedErr    string
+		expectedPanic  bool
+	}{
+		{
+			name:         "All healthy",
+			unhealthy:    map[string]bool{},
+			expectedInst: []string{"8081", "8082", "8083", "8081"},
+		},
+		{
+			name:         "One unhealthy instance is skipped",
+			unhealthy:    map[string]bool{"8082": true},
+			expectedInst: []string{"8081", "8083", "8081", "8083"},
+		},
+		{
+			name:           "Above the panic threshold",
+			unhealthy:      map[string]bool{"8082": true},
+			panicThreshold: 50,
+			expectedInst:   []string{"8081", "8083", "8081", "8083"},
+		},
+		{
+			name:           "Below the panic threshold routes to every instance",
+			unhealthy:      map[string]bool{"8081": true, "8082": true},
+			panicThreshold: 50,
+			expectedInst:   []string{"8081", "8082", "8083", "8081"},
+			expectedPanic:  true,
+		},
+		{
+			name:        "No healthy instance with panic mode disabled",
+			unhealthy:   map[string]bool{"8081": true, "8082": true, "8083": true},
+			expectedErr: "no healthy instances available",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := New([]string{"8081", "8082", "8083"},
+				WithHealth(func(instance string) bool { return !tt.unhealthy[instance] }),
+				WithPanicThreshold(tt.panicThreshold),
+			)
+
+			if tt.expectedErr != "" {
+				_, err := rr.Next()
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			for _, expected := range tt.expectedInst {
+				instance, err := rr.Next()
+				assert.NoError(t, err)
+				assert.Equal(t, expected, instance)
+			}
+			assert.Equal(t, tt.expectedPanic, rr.PanicMode())
+		})
+	}
+}
+
+// TestRoundRobin_PanicModeFollowsHealth checks that panic mode is left once enough instances recover.
+func TestRoundRobin_PanicModeFollowsHealth(t *testing.T) {
+	unhealthy := map[string]bool{"8081": true, "8082": true}
+	rr := New([]string{"8081", "8082", "8083"},
+		WithHealth(func(instance string) bool { return !unhealthy[instance] }),
+		WithPanicThreshold(50),
+	)
+
+	entered := panicModeEntered.Value()
+	assert.True(t, rr.PanicMode())
+	assert.Equal(t, entered+1, panicModeEntered.Value())
+	assert.Equal(t, 1.0, panicModeGauge.Value())
+
+	delete(unhealthy, "8081")
+	assert.False(t, rr.PanicMode())
+	assert.Equal(t, 0.0, panicModeGauge.Value())
+}
+
+// TestRoundRobin_UsesReportedState checks that draining instances get no traffic and loaded instances get less.
+func TestRoundRobin_UsesReportedState(t *testing.T) {
+	states := map[string]State{
+		"8081": {Healthy: true},
+		"8082": {Healthy: true, Load: 0.75},
+		"8083": {Healthy: true, Draining: true},
+	}
+	rr := New([]string{"8081", "8082", "8083"},
+		WithState(func(instance string) State { return states[instance] }),
+	)
+
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		instance, err := rr.Next()
+		assert.NoError(t, err)
+		counts[instance]++
+	}
+
+	// Weights 1 and 0.25 split the traffic 80/20
+	assert.Equal(t, map[string]int{"8081": 80, "8082": 20}, counts)
+}
+
+// TestRoundRobin_DrainingInstancesStayOutInPanicMode checks that panic mode does not route to draining instances.
+func TestRoundRobin_DrainingInstancesStayOutInPanicMode(t *testing.T) {
+	states := map[string]State{
+		"8081": {Healthy: false},
+		"8082": {Healthy: false},
+		"8083": {Healthy: true, Draining: true},
+	}
+	rr := New([]string{"8081", "8082", "8083"},
+		WithState(func(instance string) State { return states[instance] }),
+		WithPanicThreshold(50),
+	)
+
+	for _, expected := range []string{"8081", "8082", "8081"} {
+		instance, err := rr.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, instance)
+	}
+	assert.True(t, rr.PanicMode())
+
+	// With every instance draining there is nowhere to go
+	states["8081"] = State{Healthy: true, Draining: true}
+	states["8082"] = State{Healthy: true, Draining: true}
+	_, err := rr.Next()
+	assert.EqualError(t, err, "no healthy instances available")
+}
+
+func TestLoadFactor(t *testing.T) {
+	assert.Equal(t, 1.0, loadFactor(0))
+	assert.Equal(t, 1.0, loadFactor(-1))
+	assert.Equal(t, 0.5, loadFactor(0.5))
+	assert.Equal(t, minLoadFactor, loadFactor(1))
+	assert.Equal(t, minLoadFactor, loadFactor(3))
+}
+
+// TestRoundRobin_WithWeights checks that configured weights set each instance's share of traffic.
+func TestRoundRobin_WithWeights(t *testing.T) {
+	rr := New([]string{"a", "b", "c"}, WithWeights(map[string]int{"a": 3, "b": 1}))
+
+	sequence := make([]string, 0, 5)
+	for i := 0; i < 5; i++ {
+		instance, err := rr.Next()
+		assert.NoError(t, err)
+		sequence = append(sequence, instance)
+	}
+
+	// Smooth weighted round-robin interleaves the heavier instance instead of sending it bursts
+	assert.Equal(t, []string{"a", "b", "a", "c", "a"}, sequence)
+}
+
+// TestRoundRobin_Update checks that updating the instances takes effect on the next pick and keeps the rotation going.
+func TestRoundRobin_Update(t *testing.T) {
+	rr := New([]string{"a", "b", "c"}, WithPanicThreshold(50))
+
+	instance, err := rr.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", instance)
+
+	// "b" is next in line and keeps its position; "c" is removed and "d" added
+	rr.Update([]string{"a", "b", "d"}, WithWeights(map[string]int{"d": 2}))
+
+	sequence := make([]string, 0, 4)
+	for i := 0; i < 4; i++ {
+		instance, err := rr.Next()
+		assert.NoError(t, err)
+		sequence = append(sequence, instance)
+	}
+	assert.Equal(t, []string{"b", "d", "d", "a"}, sequence)
+	assert.Zero(t, rr.panicThreshold)
+
+	rr.Update(nil)
+	_, err = rr.Next()
+	assert.EqualError(t, err, "no instances available")
+}
+
+// TestRoundRobin_RandomStrategy checks that the random strategy only picks eligible instances, in proportion to their weight.
+func TestRoundRobin_RandomStrategy(t *testing.T) {
+	healthy := map[string]bool{"a": true, "b": true, "c": false}
+	rr := New([]string{"a", "b", "c"},
+		WithStrategy(StrategyRandom),
+		WithWeights(map[string]int{"a": 3}),
+		WithHealth(func(instance string) bool { return healthy[instance] }),
+	)
+
+	counts := make(map[string]int)
+	for i := 0; i < 4000; i++ {
+		instance, err := rr.Next()
+		assert.NoError(t, err)
+		counts[instance]++
+	}
+
+	assert.Zero(t, counts["c"])
+	assert.InDelta(t, 3000, counts["a"], 200)
+	assert.InDelta(t, 1000, counts["b"], 200)
+
+	healthy["a"], healthy["b"] = false, false
+	_, err := rr.Next()
+	assert.EqualError(t, err, "no healthy instances available")
+}
+
+// TestRoundRobin_WithName checks that named balancers report panic mode under their pool label.
+func TestRoundRobin_WithName(t *testing.T) {
+	rr := New([]string{"a", "b"},
+		WithName("api"),
+		WithHealth(func(string) bool { return false }),
+		WithPanicThreshold(50),
+	)
+
+	assert.True(t, rr.PanicMode())
+	assert.Equal(t, 1.0, metrics.Default.Gauge(panicModeGaugeName, panicModeGaugeHelp, "pool", "api").Value())
+	assert.Equal(t, uint64(1), metrics.Default.Counter(panicModeEnteredName, panicModeEnteredHelp, "pool", "api").Value())
+}
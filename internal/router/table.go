@@ -0,0 +1,131 @@
+package router
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+)
+
+// Rule is a compiled routing rule.
+type Rule struct {
+	Name   string
+	Pool   string        // Pool matching requests are forwarded to
+	Policy config.Policy // Overrides the policy of the pool for matching requests
+
+	StripPrefix string // Removed from the start of the path of matching requests
+	AddPrefix   string // Put in front of the path of matching requests, once StripPrefix is removed
+
+	host       string
+	pathPrefix string
+	pathRegex  *regexp.Regexp // nil when the rule has no path regex
+	methods    map[string]bool
+	headers    map[string]string
+}
+
+// Table picks the pool of every request from an ordered list of rules; the first matching rule wins.
+type Table struct {
+	rules []Rule
+	def   string // Pool of requests no rule matches; empty when there is none
+}
+
+// NewTable compiles the routing table of the configuration.
+func NewTable(routing config.Routing) (*Table, error) {
+	t := &Table{rules: make([]Rule, 0, len(routing.Rules)), def: routing.Default}
+	for i, r := range routing.Rules {
+		rule := Rule{
+			Name:        r.Name,
+			Pool:        r.Pool,
+			Policy:      r.Policy,
+			StripPrefix: r.StripPrefix,
+			AddPrefix:   r.AddPrefix,
+			host:        strings.ToLower(r.Match.Host),
+			pathPrefix:  r.Match.PathPrefix,
+			headers:     r.Match.Headers,
+		}
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i)
+		}
+		if r.Match.PathRegex != "" {
+			re, err := regexp.Compile(r.Match.PathRegex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path regex of routing %s: %v", rule.Name, err)
+			}
+			rule.pathRegex = re
+		}
+		if len(r.Match.Methods) > 0 {
+			rule.methods = make(map[string]bool, len(r.Match.Methods))
+			for _, method := range r.Match.Methods {
+				rule.methods[strings.ToUpper(method)] = true
+			}
+		}
+		t.rules = append(t.rules, rule)
+	}
+	return t, nil
+}
+
+// Match returns the rule picking the pool of a request.
+// Requests no rule matches go to the default pool, through a rule with no name and no policy;
+// ok is false when there is none.
+func (t *Table) Match(r *http.Request) (rule Rule, ok bool) {
+	for _, candidate := range t.rules {
+		if candidate.matches(r) {
+			return candidate, true
+		}
+	}
+	return Rule{Pool: t.def}, t.def != ""
+}
+
+// Rules returns the rules of the table, in order.
+func (t *Table) Rules() []Rule {
+	return t.rules
+}
+
+// matches reports whether the request meets every condition of the rule.
+func (rule Rule) matches(r *http.Request) bool {
+	if rule.host != "" && !matchHost(rule.host, requestHost(r)) {
+		return false
+	}
+	if rule.pathPrefix != "" && !hasPathPrefix(r.URL.Path, rule.pathPrefix) {
+		return false
+	}
+	if rule.pathRegex != nil && !rule.pathRegex.MatchString(r.URL.Path) {
+		return false
+	}
+	if rule.methods != nil && !rule.methods[r.Method] {
+		return false
+	}
+	for name, value := range rule.headers {
+		if r.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPathPrefix reports whether path starts with prefix at a segment boundary: "/api" matches "/api" and "/api/users",
+// not "/apiv2". A prefix ending with '/' matches any path starting with it.
+func hasPathPrefix(path, prefix string) bool {
+	rest, ok := strings.CutPrefix(path, prefix)
+	return ok && (rest == "" || rest[0] == '/' || strings.HasSuffix(prefix, "/"))
+}
+
+// requestHost returns the lower-cased host of a request, without port.
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// matchHost reports whether host matches pattern, where a leading "*." matches any subdomain.
+func matchHost(pattern, host string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok && strings.HasPrefix(suffix, ".") {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+	return pattern == host
+}
@@ -0,0 +1,87 @@
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTable_Match(t *testing.T) {
+	table, err := NewTable(config.Routing{
+		Rules: []config.RoutingRule{
+			{Name: "admin", Match: config.Match{Host: "admin.example.com"}, Pool: "admin"},
+			{Name: "tenants", Match: config.Match{Host: "*.tenants.example.com"}, Pool: "tenants"},
+			{Name: "api-writes", Match: config.Match{PathPrefix: "/api/", Methods: []string{"POST", "put"}}, Pool: "api-primary"},
+			{Name: "api", Match: config.Match{PathPrefix: "/api"}, Pool: "api"},
+			{Name: "reports", Match: config.Match{PathRegex: `^/reports/\d+$`}, Pool: "reports"},
+			{Name: "canary", Match: config.Match{Headers: map[string]string{"X-Canary": "true"}}, Pool: "canary"},
+		},
+		Default: "web",
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		method       string
+		target       string
+		headers      map[string]string
+		expectedPool string
+		expectedRule string
+	}{
+		{name: "Exact host with port", method: "GET", target: "http://Admin.Example.com:8080/api/users", expectedPool: "admin", expectedRule: "admin"},
+		{name: "Wildcard host", method: "GET", target: "http://acme.tenants.example.com/", expectedPool: "tenants", expectedRule: "tenants"},
+		{name: "Wildcard does not match the bare domain", method: "GET", target: "http://tenants.example.com/", expectedPool: "web"},
+		{name: "Method and prefix", method: "PUT", target: "http://example.com/api/users/1", expectedPool: "api-primary", expectedRule: "api-writes"},
+		{name: "First match wins", method: "GET", target: "http://example.com/api/users/1", expectedPool: "api", expectedRule: "api"},
+		{name: "Prefix itself", method: "GET", target: "http://example.com/api", expectedPool: "api", expectedRule: "api"},
+		{name: "Prefix then segment", method: "GET", target: "http://example.com/api/x", expectedPool: "api", expectedRule: "api"},
+		{name: "Prefix within a segment", method: "GET", target: "http://example.com/apiv2", expectedPool: "web"},
+		{name: "Regex", method: "GET", target: "http://example.com/reports/42", expectedPool: "reports", expectedRule: "reports"},
+		{name: "Regex mismatch", method: "GET", target: "http://example.com/reports/latest", expectedPool: "web"},
+		{name: "Header", method: "GET", target: "http://example.com/", headers: map[string]string{"X-Canary": "true"}, expectedPool: "canary", expectedRule: "canary"},
+		{name: "Default", method: "GET", target: "http://example.com/index.html", expectedPool: "web"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+
+			rule, ok := table.Match(req)
+			assert.True(t, ok)
+			assert.Equal(t, tt.expectedPool, rule.Pool)
+			assert.Equal(t, tt.expectedRule, rule.Name)
+		})
+	}
+}
+
+func TestTable_NoDefault(t *testing.T) {
+	policy := config.Policy{MaxBodyBytes: 1024}
+	table, err := NewTable(config.Routing{Rules: []config.RoutingRule{{
+		Match:       config.Match{PathPrefix: "/api/"},
+		Pool:        "api",
+		Policy:      policy,
+		StripPrefix: "/api",
+		AddPrefix:   "/v2",
+	}}})
+	assert.NoError(t, err)
+
+	rule, ok := table.Match(httptest.NewRequest("GET", "/api/users", nil))
+	assert.True(t, ok)
+	assert.Equal(t, "rule 0", rule.Name)
+	assert.Equal(t, policy, rule.Policy)
+	assert.Equal(t, "/api", rule.StripPrefix)
+	assert.Equal(t, "/v2", rule.AddPrefix)
+
+	_, ok = table.Match(httptest.NewRequest("GET", "/", nil))
+	assert.False(t, ok)
+}
+
+func TestNewTable_InvalidRegex(t *testing.T) {
+	_, err := NewTable(config.Routing{Rules: []config.RoutingRule{{Name: "broken", Match: config.Match{PathRegex: "(["}}}})
+	assert.ErrorContains(t, err, "invalid path regex of routing broken")
+}
@@ -5,7 +5,6 @@ import (
 	"log"
 	"net/http"
 	"sync"
-	"time"
 
 	"github.com/samargupta114/Roundrobinator.git/internal/health"
 
@@ -25,12 +24,30 @@ type ServerWrapper struct {
 }
 
 // ApplicationServer implements the ServerLauncher interface for Application APIs.
-type ApplicationServer struct{}
+type ApplicationServer struct {
+	// Checks holds the dependency checks reported by /health.
+	// When nil, Launch builds it from the checks in the configuration.
+	Checks *health.Registry
+}
 
 // Launch starts multiple Application API instances.
 func (as *ApplicationServer) Launch(ctx context.Context, cfg *config.Config, wg *sync.WaitGroup) error {
-	// Loop through each route in the configuration and launch a server on each port.
-	for _, port := range cfg.Backend.Routes {
+	// Set up the dependency checks shared by every instance
+	if as.Checks == nil {
+		registry, err := health.RegistryFromConfig(cfg.Backend.Checks)
+		if err != nil {
+			return err
+		}
+		as.Checks = registry
+	}
+
+	// Loop through each route in the configuration and launch a server on each local port.
+	// Routes given as full URLs point at backends running elsewhere.
+	for _, route := range cfg.Backend.Routes {
+		port, ok := route.Port()
+		if !ok {
+			continue
+		}
 		wg.Add(1)                                // Add to the WaitGroup for each server to be launched concurrently.
 		go as.startAppServer(ctx, port, cfg, wg) // Start the server in a separate goroutine.
 	}
@@ -43,10 +60,15 @@ func (as *ApplicationServer) startAppServer(ctx context.Context, port string, cf
 
 	// Create a new ServeMux to handle HTTP routes
 	mux := http.NewServeMux()
-	// Register the `/health` route to monitor the health of the server
-	mux.HandleFunc(cfg.Backend.Endpoint[Healthcheck].URL, health.HealthCheckHandler)
-	// Register the `/mirror` route to handle the main API functionality
+	// Register the `/health` route to monitor the health of the server and its dependencies
+	healthHandler := health.HealthCheckHandler
+	if as.Checks != nil {
+		healthHandler = as.Checks.Handler
+	}
+	mux.HandleFunc(cfg.Backend.Endpoint[Healthcheck].URL, healthHandler)
+	// Register the `/mirror` route, and every path below it, to handle the main API functionality
 	mux.HandleFunc("/mirror", handler.ApplicationAPIHandler)
+	mux.HandleFunc("/mirror/", handler.ApplicationAPIHandler)
 
 	// Create a new HTTP server instance with the specified port and handler
 	server := &ServerWrapper{Server: &http.Server{Addr: ":" + port, Handler: mux}}
@@ -74,7 +96,7 @@ func (as *ApplicationServer) runServer(ctx context.Context, server *ServerWrappe
 	go func() {
 		<-ctx.Done() // Wait for the cancellation signal
 		// Once canceled, start the graceful shutdown with a timeout
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.GracefulTimeoutSeconds)*time.Second)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.GracefulTimeout.Duration())
 		defer cancel()
 
 		// Attempt to shut down the server gracefully, and report errors if any
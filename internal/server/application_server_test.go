@@ -28,12 +28,12 @@ func TestStartAppServer(t *testing.T) {
 	// Mock config
 	cfg := &config.Config{
 		Backend: config.Backend{
-			Routes: []string{"8081"},
+			Routes: config.Routes("8081"),
 			Endpoint: map[string]config.Endpoint{
 				"healthcheck": {URL: "/health"},
 			},
 		},
-		GracefulTimeoutSeconds: 5,
+		GracefulTimeout: config.Seconds(5),
 	}
 
 	// Initialize the ApplicationServer
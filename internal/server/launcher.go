@@ -11,3 +11,8 @@ import (
 type ServerLauncher interface {
 	Launch(ctx context.Context, cfg *config.Config, wg *sync.WaitGroup) error
 }
+
+// Reloader is implemented by servers that can apply a new configuration without restarting.
+type Reloader interface {
+	Reload(cfg *config.Config)
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+)
+
+// Reload configures how Launch reloads the configuration while the servers run.
+type Reload struct {
+	// File is the config file watched for changes, together with the fragments of its include_dir,
+	// when reload.watch_interval_seconds is set.
+	File string
+
+	// Load resolves and validates the configuration again. Reloading is disabled when nil.
+	Load func() (*config.Config, error)
+}
+
+// fileStamp identifies a version of a config file.
+type fileStamp struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// stat returns the stamp of the file at path, without modification time and size when it cannot be read.
+func stat(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{path: path}
+	}
+	return fileStamp{path: path, modTime: info.ModTime(), size: info.Size()}
+}
+
+// stamps returns the stamps of the config file and of the fragments of its include_dir,
+// so adding, removing or changing a fragment changes them too.
+func stamps(cfg *config.Config, file string) []fileStamp {
+	result := []fileStamp{stat(file)}
+	fragments, _ := cfg.FragmentFiles(file) // An unreadable directory fails the next reload instead
+	for _, fragment := range fragments {
+		result = append(result, stat(fragment))
+	}
+	return result
+}
+
+// watchReload reloads the configuration on SIGHUP and, when reload.watch_interval_seconds is set,
+// whenever the config file changes, until ctx is canceled.
+// apply is called with every new configuration that loads and validates; invalid ones are logged and ignored.
+func watchReload(ctx context.Context, cfg *config.Config, reload Reload, apply func(*config.Config)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	stamp := stamps(cfg, reload.File)
+	for {
+		var poll <-chan time.Time
+		if interval := cfg.Reload.WatchInterval; interval > 0 && reload.File != "" {
+			poll = time.After(interval.Duration())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Println("Reload signal received. Reloading configuration...")
+		case <-poll:
+			if slices.Equal(stamps(cfg, reload.File), stamp) {
+				continue
+			}
+			log.Printf("Config file %s changed. Reloading configuration...", reload.File)
+		}
+		stamp = stamps(cfg, reload.File)
+
+		next, err := reload.Load()
+		if err != nil {
+			//push alerts
+			log.Printf("Rejected configuration reload, keeping the current configuration: %v", err)
+			continue
+		}
+		if fields := restartRequired(cfg, next); len(fields) > 0 {
+			log.Printf("Configuration changes to %v take effect after a restart", fields)
+		}
+		apply(next)
+		cfg = next
+		log.Printf("Configuration reloaded: %d backend routes", len(next.Backend.Routes))
+	}
+}
+
+// restartRequired returns the settings that differ between old and next but are only read at startup.
+func restartRequired(old, next *config.Config) []string {
+	var fields []string
+	if old.Server.Port != next.Server.Port {
+		fields = append(fields, "server.port")
+	}
+	if old.Backend.Endpoint[Healthcheck].URL != next.Backend.Endpoint[Healthcheck].URL {
+		fields = append(fields, "backend.endpoints.healthcheck.url")
+	}
+	if !reflect.DeepEqual(localPorts(old), localPorts(next)) {
+		fields = append(fields, "backend.routes (local Application API servers)")
+	}
+	if !reflect.DeepEqual(old.Backend.Checks, next.Backend.Checks) {
+		fields = append(fields, "backend.checks")
+	}
+	if old.HealthCheck.HistorySize != next.HealthCheck.HistorySize {
+		fields = append(fields, "health_check.history_size")
+	}
+	if old.HealthCheck.HistoryFile != next.HealthCheck.HistoryFile {
+		fields = append(fields, "health_check.history_file")
+	}
+	return fields
+}
+
+// localPorts returns the ports of the bare-port routes, which Application API servers are started on.
+func localPorts(cfg *config.Config) []string {
+	var ports []string
+	for _, route := range cfg.Backend.Routes {
+		if port, ok := route.Port(); ok {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samargupta114/Roundrobinator.git/internal/config"
+	"github.com/samargupta114/Roundrobinator.git/internal/handler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchReload_AppliesValidChangesOnly(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	file := filepath.Join(t.TempDir(), "app-config.json")
+	assert.NoError(t, os.WriteFile(file, []byte("{}"), 0o644))
+
+	cfg := &config.Config{Reload: config.Reload{WatchInterval: config.Seconds(1)}}
+	next := &config.Config{Backend: config.Backend{Routes: config.Routes("8081", "8082")}, Reload: cfg.Reload}
+
+	var mu sync.Mutex
+	loadErr := errors.New("invalid config")
+	var applied []*config.Config
+	reload := Reload{
+		File: file,
+		Load: func() (*config.Config, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if loadErr != nil {
+				return nil, loadErr
+			}
+			return next, nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		watchReload(ctx, cfg, reload, func(c *config.Config) {
+			mu.Lock()
+			defer mu.Unlock()
+			applied = append(applied, c)
+		})
+		close(done)
+	}()
+
+	// An invalid config is rejected and the current one stays active
+	assert.NoError(t, os.WriteFile(file, []byte(`{"server": {}}`), 0o644))
+	time.Sleep(1500 * time.Millisecond)
+	mu.Lock()
+	assert.Empty(t, applied)
+	loadErr = nil
+	mu.Unlock()
+
+	// A valid one is applied once the file changes again
+	assert.NoError(t, os.WriteFile(file, []byte(`{"server": {"port": "8080"}}`), 0o644))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(applied) == 1 && applied[0] == next
+	}, 3*time.Second, 50*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("watchReload did not return after the context was canceled")
+	}
+}
+
+func TestStamps_Fragments(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app-config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("include_dir: conf.d\n"), 0o644))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "conf.d"), 0o755))
+	cfg := &config.Config{IncludeDir: "conf.d"}
+
+	before := stamps(cfg, file)
+	assert.Equal(t, before, stamps(cfg, file))
+
+	// Adding a fragment changes the stamps, as does changing it
+	fragment := filepath.Join(dir, "conf.d", "api.yaml")
+	assert.NoError(t, os.WriteFile(fragment, []byte("pools: {}\n"), 0o644))
+	added := stamps(cfg, file)
+	assert.NotEqual(t, before, added)
+
+	assert.NoError(t, os.WriteFile(fragment, []byte("pools: {api: {}}\n"), 0o644))
+	assert.NotEqual(t, added, stamps(cfg, file))
+}
+
+func TestRoundRobinServer_Reload(t *testing.T) {
+	rrs := &RoundRobinServer{pools: make(map[string]*backendPool)}
+	rrs.apply(&config.Config{
+		Server:  config.Server{Timeout: config.Seconds(5)},
+		Backend: config.Backend{Routes: config.Routes("8081")},
+	})
+	defaultPool := rrs.pools[config.DefaultPool]
+
+	rrs.Reload(&config.Config{
+		Server:  config.Server{Timeout: config.Seconds(1)},
+		Backend: config.Backend{Routes: []config.Route{{URL: "https://api.internal"}}},
+		Pools:   map[string]config.Pool{"static": {Backends: config.Routes("https://cdn.internal"), Strategy: config.StrategyRandom}},
+		Routing: config.Routing{
+			Rules: []config.RoutingRule{
+				{Match: config.Match{PathPrefix: "/static/"}, Pool: "static"},
+				{Match: config.Match{PathPrefix: "/upload/"}, Pool: config.DefaultPool, Policy: config.Policy{Timeout: config.Seconds(60), MaxBodyBytes: 1024}},
+			},
+			Default: config.DefaultPool,
+		},
+		GracefulTimeout: config.Seconds(3),
+	})
+
+	// The default pool keeps its balancer and client with the new backends and timeout
+	assert.Same(t, defaultPool.rr, rrs.pools[config.DefaultPool].rr)
+	assert.Same(t, defaultPool.client, rrs.pools[config.DefaultPool].client)
+	assert.Equal(t, config.Seconds(1), rrs.pools[config.DefaultPool].policy.Timeout)
+	instance, err := defaultPool.rr.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://api.internal", instance)
+	assert.Equal(t, config.Seconds(3), rrs.config().GracefulTimeout)
+
+	// New pools are reachable through the new routing table
+	pool, ok := rrs.route(httptest.NewRequest(http.MethodGet, "/static/app.js", nil))
+	assert.True(t, ok)
+	instance, err = pool.Balancer.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://cdn.internal", instance)
+
+	pool, ok = rrs.route(httptest.NewRequest(http.MethodGet, "/index.html", nil))
+	assert.True(t, ok)
+	assert.Equal(t, defaultPool.rr, pool.Balancer)
+	assert.Equal(t, defaultPool.client, pool.Client)
+
+	// Rules overriding the policy of the pool get a client with their own timeouts
+	pool, ok = rrs.route(httptest.NewRequest(http.MethodPost, "/upload/file", nil))
+	assert.True(t, ok)
+	assert.Equal(t, defaultPool.rr, pool.Balancer)
+	assert.NotEqual(t, defaultPool.client, pool.Client)
+	assert.Equal(t, config.Policy{Timeout: config.Seconds(60), MaxBodyBytes: 1024}, pool.Policy)
+}
+
+func TestRoundRobinServer_LegacyRoutePolicy(t *testing.T) {
+	// A port nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	refused := "http://" + listener.Addr().String()
+	listener.Close()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.URL.Path)
+	}))
+	defer backend.Close()
+
+	rrs := &RoundRobinServer{pools: make(map[string]*backendPool)}
+	rrs.apply(&config.Config{
+		Server:  config.Server{Policy: config.Policy{Retry: config.Retry{Attempts: 3}}},
+		Backend: config.Backend{Routes: config.Routes(refused, backend.URL)},
+	})
+
+	// /route retries on the default pool as routed requests do
+	rr := httptest.NewRecorder()
+	handler.PoolRouteHandler(rrs.defaultPool)(rr, httptest.NewRequest(http.MethodGet, "/route/x", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "/mirror/x", rr.Body.String())
+	assert.Equal(t, "2", rr.Header().Get("X-Upstream-Attempts"))
+}
+
+func TestRestartRequired(t *testing.T) {
+	old := &config.Config{
+		Server: config.Server{Port: "8080", Timeout: config.Seconds(5)},
+		Backend: config.Backend{
+			Routes:   config.Routes("8081", "https://api.internal"),
+			Endpoint: map[string]config.Endpoint{Healthcheck: {URL: "/health", Timeout: config.Seconds(2)}},
+		},
+	}
+
+	// Backends given as URLs, weights and timeouts are all applied live
+	next := *old
+	next.Server.Timeout = config.Seconds(1)
+	next.Backend.Routes = []config.Route{{URL: "8081", Weight: 3}, {URL: "https://other.internal"}}
+	next.Backend.Endpoint = map[string]config.Endpoint{Healthcheck: {URL: "/health", Timeout: config.Seconds(1)}}
+	assert.Empty(t, restartRequired(old, &next))
+
+	next.Server.Port = "9090"
+	next.Backend.Routes = config.Routes("8081", "8082")
+	next.Backend.Endpoint = map[string]config.Endpoint{Healthcheck: {URL: "/healthz"}}
+	next.HealthCheck.HistorySize = 10
+	assert.Equal(t, []string{
+		"server.port",
+		"backend.endpoints.healthcheck.url",
+		"backend.routes (local Application API servers)",
+		"health_check.history_size",
+	}, restartRequired(old, &next))
+}
@@ -5,33 +5,79 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"net/netip"
 	"sync"
-	"time"
 
 	"github.com/samargupta114/Roundrobinator.git/internal/health"
 
 	"github.com/samargupta114/Roundrobinator.git/internal/config"
 	"github.com/samargupta114/Roundrobinator.git/internal/handler"
+	"github.com/samargupta114/Roundrobinator.git/internal/metrics"
 	"github.com/samargupta114/Roundrobinator.git/internal/roundrobin"
+	"github.com/samargupta114/Roundrobinator.git/internal/router"
 	"github.com/samargupta114/Roundrobinator.git/pkg/utils/httpclient"
 )
 
 // RoundRobinServer implements the ServerLauncher interface for Round Robin API.
-type RoundRobinServer struct{}
+type RoundRobinServer struct {
+	// Checker reports backend health for the readiness and admin endpoints; they are not served when nil.
+	Checker *health.Checker
+
+	mu    sync.RWMutex
+	cfg   *config.Config          // Configuration currently applied
+	pools map[string]*backendPool // Balancer and client of every pool, by name; nil until launched
+	table *router.Table           // Routing table picking the pool of every request; nil until launched
+
+	trusted []netip.Prefix // Networks of the proxies whose forwarded headers are kept
+}
+
+// backendPool is the balancer and clients serving the backends of one pool.
+type backendPool struct {
+	rr     *roundrobin.RoundRobin
+	policy config.Policy        // Policy of the pool, merged over server.policy
+	client *httpclient.Client   // Client enforcing the timeouts of the pool
+	budget *handler.RetryBudget // Retries of the pool, shared by its routing rules
+
+	// overrides holds the clients of the routing rules whose policy overrides the timeouts of the pool, by timeouts.
+	overrides map[httpclient.Options]*httpclient.Client
+}
 
 // Launch starts the Round Robin API server.
 func (rrs *RoundRobinServer) Launch(ctx context.Context, cfg *config.Config, wg *sync.WaitGroup) error {
 	mux := http.NewServeMux()
 
-	// Created a round-robin instance to distribute requests to backend servers
-	rr := roundrobin.New(cfg.Backend.Routes)
-	client := httpclient.NewClient(cfg.Server.Timeout)
+	// Created a round-robin instance per pool to distribute requests to backend servers,
+	// skipping backends that fail their health checks or are draining and favouring lightly loaded ones
+	rrs.mu.Lock()
+	rrs.pools = make(map[string]*backendPool)
+	rrs.apply(cfg)
+	rrs.mu.Unlock()
+	if rrs.Checker != nil {
+		rrs.Checker.ReportPanicMode(rrs.panicMode)
+	}
 
 	// Healthcheck endpoint
 	mux.HandleFunc(cfg.Backend.Endpoint[Healthcheck].URL, health.HealthCheckHandler)
 
-	// Route for handling round-robin logic
-	mux.HandleFunc("/route", handler.RouteHandler(rr, client))
+	// Metrics endpoint
+	mux.HandleFunc("/metrics", metrics.Default.Handler)
+
+	// Liveness and readiness endpoints
+	mux.HandleFunc("/livez", health.LivenessHandler)
+	if rrs.Checker != nil {
+		mux.HandleFunc("/readyz", rrs.Checker.ReadinessHandler)
+
+		// Admin endpoints
+		mux.HandleFunc("/admin/health/history", rrs.Checker.HistoryHandler)
+	}
+
+	// Route for handling round-robin logic over the default pool, at /route and every path below it
+	legacy := handler.PoolRouteHandler(rrs.defaultPool)
+	mux.HandleFunc("/route", legacy)
+	mux.HandleFunc("/route/", legacy)
+
+	// Every other request is routed to a pool through the routing table
+	mux.HandleFunc("/", handler.RoutingHandler(rrs.route))
 
 	server := &http.Server{
 		Addr:    ":" + cfg.Server.Port, // Ensure this is the correct port
@@ -42,7 +88,7 @@ func (rrs *RoundRobinServer) Launch(ctx context.Context, cfg *config.Config, wg
 	go func() {
 		<-ctx.Done()
 		log.Printf("Shutting down Round Robin API on port %s...", cfg.Server.Port)
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.GracefulTimeoutSeconds)*time.Second)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), rrs.config().GracefulTimeout.Duration())
 		defer cancel()
 		err := server.Shutdown(shutdownCtx)
 		if err != nil {
@@ -58,3 +104,179 @@ func (rrs *RoundRobinServer) Launch(ctx context.Context, cfg *config.Config, wg
 
 	return nil
 }
+
+// Reload applies a new configuration to the running server: every balancer swaps its backends, weights
+// and panic threshold at once, pools are added and removed, the routing table is replaced, and routed
+// requests use the new policies from then on.
+func (rrs *RoundRobinServer) Reload(cfg *config.Config) {
+	rrs.mu.Lock()
+	defer rrs.mu.Unlock()
+
+	if rrs.pools == nil {
+		rrs.cfg = cfg
+		return // Not launched yet
+	}
+	rrs.apply(cfg)
+}
+
+// apply builds or updates the pools and the routing table of the given configuration. rrs.mu must be held.
+// Pools that already exist keep their balancer, so backends keep their position in the rotation.
+func (rrs *RoundRobinServer) apply(cfg *config.Config) {
+	table, err := router.NewTable(cfg.Routing)
+	if err != nil {
+		log.Printf("Keeping the current routing table: %v", err) // Validation rejects invalid tables
+		table = rrs.table
+	}
+
+	pools := make(map[string]*backendPool)
+	for name, pool := range cfg.BackendPools() {
+		names, opts := rrs.balancer(cfg, name, pool)
+		existing, ok := rrs.pools[name]
+		if ok {
+			existing.rr.Update(names, opts...)
+			existing.client.Configure(clientOptions(pool.Policy))
+		} else {
+			existing = &backendPool{
+				rr:     roundrobin.New(names, opts...),
+				client: httpclient.New(clientOptions(pool.Policy)),
+				budget: &handler.RetryBudget{},
+			}
+		}
+		pools[name] = &backendPool{
+			rr:        existing.rr,
+			policy:    pool.Policy,
+			client:    existing.client,
+			budget:    existing.budget,
+			overrides: make(map[httpclient.Options]*httpclient.Client),
+		}
+
+		// Routing rules overriding the timeouts get a client of their own, kept across reloads while they are unchanged
+		for _, rule := range table.Rules() {
+			options := clientOptions(pool.Policy.Merge(rule.Policy))
+			if rule.Pool != name || options == clientOptions(pool.Policy) {
+				continue
+			}
+			if client, ok := existing.overrides[options]; ok {
+				pools[name].overrides[options] = client
+			} else {
+				pools[name].overrides[options] = httpclient.New(options)
+			}
+		}
+	}
+	rrs.cfg, rrs.pools, rrs.table = cfg, pools, table
+	rrs.trusted = cfg.Server.TrustedNetworks()
+}
+
+// config returns the configuration currently applied.
+func (rrs *RoundRobinServer) config() *config.Config {
+	rrs.mu.RLock()
+	defer rrs.mu.RUnlock()
+	return rrs.cfg
+}
+
+// pool returns the balancer, client, policy and retry budget of the named pool.
+func (rrs *RoundRobinServer) pool(name string) (handler.Pool, bool) {
+	rrs.mu.RLock()
+	defer rrs.mu.RUnlock()
+
+	pool, ok := rrs.pools[name]
+	if !ok {
+		return handler.Pool{}, false
+	}
+	return handler.Pool{Balancer: pool.rr, Client: pool.client, Policy: pool.policy, TrustedProxies: rrs.trusted, Budget: pool.budget}, true
+}
+
+// defaultPool returns the default pool, with its policy, for every request to the legacy /route endpoint.
+func (rrs *RoundRobinServer) defaultPool(*http.Request) (handler.Pool, bool) {
+	return rrs.pool(config.DefaultPool)
+}
+
+// route returns the pool the routing table picks for a request, with the policy and path rewriting of the matching rule.
+func (rrs *RoundRobinServer) route(r *http.Request) (handler.Pool, bool) {
+	rrs.mu.RLock()
+	defer rrs.mu.RUnlock()
+
+	rule, ok := rrs.table.Match(r)
+	if !ok {
+		return handler.Pool{}, false
+	}
+	pool, ok := rrs.pools[rule.Pool]
+	if !ok {
+		return handler.Pool{}, false
+	}
+
+	policy := pool.policy.Merge(rule.Policy)
+	client, ok := pool.overrides[clientOptions(policy)]
+	if !ok {
+		client = pool.client
+	}
+	return handler.Pool{
+		Balancer:       pool.rr,
+		Client:         client,
+		Policy:         policy,
+		StripPrefix:    rule.StripPrefix,
+		AddPrefix:      rule.AddPrefix,
+		TrustedProxies: rrs.trusted,
+		Budget:         pool.budget,
+	}, true
+}
+
+// clientOptions returns the client timeouts of a policy.
+func clientOptions(policy config.Policy) httpclient.Options {
+	return httpclient.Options{
+		Timeout:               policy.Timeout.Duration(),
+		ConnectTimeout:        policy.ConnectTimeout.Duration(),
+		ResponseHeaderTimeout: policy.ResponseHeaderTimeout.Duration(),
+	}
+}
+
+// panicMode reports whether the balancer of any pool ignores health.
+func (rrs *RoundRobinServer) panicMode() bool {
+	rrs.mu.RLock()
+	defer rrs.mu.RUnlock()
+
+	for _, pool := range rrs.pools {
+		if pool.rr.PanicMode() {
+			return true
+		}
+	}
+	return false
+}
+
+// balancer returns the backends of the balancer of a pool and its options for the given configuration.
+func (rrs *RoundRobinServer) balancer(cfg *config.Config, name string, pool config.Pool) ([]string, []roundrobin.Option) {
+	names := make([]string, len(pool.Backends))
+	weights := make(map[string]int, len(pool.Backends))
+	for i, route := range pool.Backends {
+		names[i] = route.Name()
+		weights[route.Name()] = route.EffectiveWeight()
+	}
+
+	opts := []roundrobin.Option{roundrobin.WithWeights(weights)}
+	if name != config.DefaultPool {
+		opts = append(opts, roundrobin.WithName(name)) // The default pool keeps the unlabelled metrics
+	}
+	if pool.Strategy == config.StrategyRandom {
+		opts = append(opts, roundrobin.WithStrategy(roundrobin.StrategyRandom))
+	}
+	if rrs.Checker != nil {
+		opts = append(opts,
+			roundrobin.WithState(balancerState(rrs.Checker)),
+			roundrobin.WithPanicThreshold(cfg.HealthCheck.PanicThresholdPercent),
+		)
+	}
+	return names, opts
+}
+
+// balancerState reports the health check results of a backend in the form the balancer uses.
+// Backends that have not been probed yet are given the benefit of the doubt.
+func balancerState(checker *health.Checker) roundrobin.StateFunc {
+	return func(instance string) roundrobin.State {
+		status := checker.Status(instance)
+		return roundrobin.State{
+			Healthy:  status.State != health.StateUnhealthy,
+			Draining: status.State == health.StateDraining,
+			Load:     status.Load,
+		}
+	}
+}
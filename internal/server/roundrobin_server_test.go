@@ -28,15 +28,15 @@ func TestLaunch(t *testing.T) {
 	cfg := &config.Config{
 		Server: config.Server{
 			Port:    "8080",
-			Timeout: 5,
+			Timeout: config.Seconds(5),
 		},
 		Backend: config.Backend{
-			Routes: []string{"http://localhost:8081", "http://localhost:8082"},
+			Routes: config.Routes("http://localhost:8081", "http://localhost:8082"),
 			Endpoint: map[string]config.Endpoint{
 				"healthcheck": {URL: "/health"},
 			},
 		},
-		GracefulTimeoutSeconds: 5,
+		GracefulTimeout: config.Seconds(5),
 	}
 
 	// Create mock instances for roundrobin and httpclient
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
@@ -13,11 +14,12 @@ import (
 )
 
 const (
-	Healthcheck = "healthcheck" // Constant for health check endpoint
+	Healthcheck = config.HealthcheckEndpoint // Constant for health check endpoint
 )
 
 // Launch initializes and starts both the Application API and Round Robin API.
-func Launch(cfg *config.Config) {
+// The configuration is reloaded as described by reload while the servers run.
+func Launch(cfg *config.Config, reload Reload) {
 	//  WaitGroup to manage concurrent server launches and graceful shutdown
 	var wg sync.WaitGroup
 
@@ -29,24 +31,55 @@ func Launch(cfg *config.Config) {
 	// Notify signalChan on receiving Interrupt or SIGTERM signals
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 
+	// Health checker shared by the background probes and the Round Robin API readiness endpoints
+	checker := health.NewChecker(cfg, http.DefaultClient)
+	if cfg.HealthCheck.HistoryFile != "" {
+		if err := checker.PersistHistory(cfg.HealthCheck.HistoryFile); err != nil {
+			//push alerts
+			log.Fatalf("Failed to set up health history: %v", err)
+		}
+	}
+
 	// List of servers that implement the ServerLauncher interface
 	// These servers will be launched concurrently
 	servers := []ServerLauncher{
-		&ApplicationServer{}, // Application API server
-		&RoundRobinServer{},  // Round Robin API server
+		&ApplicationServer{},                // Application API server
+		&RoundRobinServer{Checker: checker}, // Round Robin API server
 	}
 
 	// Launch each server in a separate goroutine
 	for _, server := range servers {
 		wg.Add(1) // Increment the WaitGroup counter for each server launch
 		go func(srv ServerLauncher) {
-			defer wg.Done()           // Decrement the WaitGroup counter when the server finishes
-			srv.Launch(ctx, cfg, &wg) // Start the server, passing the context, config, and WaitGroup
+			defer wg.Done() // Decrement the WaitGroup counter when the server finishes
+			// Start the server, passing the context, config, and WaitGroup
+			if err := srv.Launch(ctx, cfg, &wg); err != nil {
+				//push alerts
+				log.Printf("Failed to launch server: %v", err)
+			}
 		}(server)
 	}
 
-	// Start health check monitoring in a separate goroutine
-	go health.StartHealthCheck(cfg, &wg)
+	// Start health check monitoring in a separate goroutine, stopped by the same context as the servers
+	wg.Add(1)
+	go health.StartHealthCheck(ctx, checker, &wg)
+
+	// Reload the configuration on SIGHUP or when the config file changes, without dropping traffic:
+	// health targets are updated first, so new backends are known before the balancer routes to them
+	if reload.Load != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watchReload(ctx, cfg, reload, func(next *config.Config) {
+				checker.Update(next)
+				for _, srv := range servers {
+					if reloader, ok := srv.(Reloader); ok {
+						reloader.Reload(next)
+					}
+				}
+			})
+		}()
+	}
 
 	// Start a goroutine to handle graceful shutdown on receiving system signals
 	go func() {
@@ -1,23 +1,13 @@
 package main
 
 import (
-	"log"
+	"os"
 
-	"github.com/samargupta114/Roundrobinator.git/internal/config"
-	"github.com/samargupta114/Roundrobinator.git/internal/server"
+	"github.com/samargupta114/Roundrobinator.git/internal/cli"
 )
 
 // Main function is the entry point of the application.
-// It starts the server using the Launch method.
+// It runs the command line, launching the servers unless another command is given.
 func main() {
-
-	// Load the configuration
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		//push alerts
-		log.Fatalf("Failed to load config: %v", err)
-	}
-
-	// Launch the servers
-	server.Launch(cfg)
+	os.Exit(cli.Run(os.Args[1:], os.Environ(), os.Stdout, os.Stderr))
 }
@@ -1,15 +1,24 @@
 package httpclient
 
 import (
-	"bytes"
-	"io"
+	"net"
 	"net/http"
+	"sync"
 	"time"
 )
 
-// Client struct wraps an HTTP client with a configurable timeout.
+// Options holds the timeouts of forwarded requests. A zero value disables the corresponding limit.
+type Options struct {
+	Timeout               time.Duration // Whole request, including reading the response body
+	ConnectTimeout        time.Duration // Establishing the connection to the backend
+	ResponseHeaderTimeout time.Duration // Waiting for the response headers once the request is sent
+}
+
+// Client struct wraps an HTTP client with configurable timeouts.
 type Client struct {
+	mu     sync.RWMutex // Guards client and opts, which Configure replaces
 	client *http.Client
+	opts   Options
 }
 
 // ClientInterface defines the methods that our mock and actual client will implement.
@@ -19,18 +28,72 @@ type ClientInterface interface {
 
 // NewClient creates a new HTTP client with the specified timeout in seconds.
 func NewClient(timeout int) *Client {
-	return &Client{client: &http.Client{Timeout: time.Duration(timeout) * time.Second}}
+	return New(Options{Timeout: time.Duration(timeout) * time.Second})
+}
+
+// New creates a new HTTP client with the given options.
+func New(opts Options) *Client {
+	return &Client{client: &http.Client{Timeout: opts.Timeout, Transport: newTransport(opts)}, opts: opts}
+}
+
+// Configure changes the options of the requests forwarded from now on.
+// Requests already in flight keep the options they started with, and the connection pool is kept
+// unless the connection timeouts change.
+func (c *Client) Configure(opts Options) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	client := *c.client
+	client.Timeout = opts.Timeout
+	if opts.ConnectTimeout != c.opts.ConnectTimeout || opts.ResponseHeaderTimeout != c.opts.ResponseHeaderTimeout {
+		// Only close the pool of a transport built here: a nil one is the shared http.DefaultTransport
+		if c.client.Transport != nil {
+			c.client.CloseIdleConnections()
+		}
+		client.Transport = newTransport(opts)
+	}
+	c.client, c.opts = &client, opts
+}
+
+// newTransport returns the transport enforcing the connection timeouts of opts,
+// or nil for the default transport when there are none.
+func newTransport(opts Options) http.RoundTripper {
+	if opts.ConnectTimeout == 0 && opts.ResponseHeaderTimeout == 0 {
+		return nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: opts.ConnectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.ResponseHeaderTimeout = opts.ResponseHeaderTimeout
+	return transport
 }
 
 // ForwardRequest forwards an incoming HTTP request to the target URL and returns the response.
+// The body of the request is streamed to the backend as it is read, never buffered, and the request is cancelled
+// along with the incoming one. Its headers are sent without the hop-by-hop ones; req itself is left unchanged.
+// An error reading the body fails the request.
 func (c *Client) ForwardRequest(req *http.Request, url string) (*http.Response, error) {
-	// Read the body of the incoming request
-	body, _ := io.ReadAll(req.Body)
-
-	// Create a new HTTP request with the same method, headers, and body
-	newReq, _ := http.NewRequest(req.Method, url, bytes.NewReader(body))
-	newReq.Header = req.Header
+	// Create a new HTTP request with the same method, end-to-end headers, and body
+	body := req.Body
+	if body == nil || req.ContentLength == 0 {
+		body = http.NoBody
+	}
+	newReq, err := http.NewRequestWithContext(req.Context(), req.Method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != http.NoBody {
+		newReq.ContentLength = req.ContentLength // -1 when unknown, sending the body chunked
+	}
+	newReq.Header = req.Header.Clone()
+	if newReq.Header == nil {
+		newReq.Header = make(http.Header)
+	}
+	RemoveHopByHop(newReq.Header)
 
-	// Send the request using the client's HTTP client
-	return c.client.Do(newReq)
+	// Send the request using the client's HTTP client. An Expect: 100-continue header is forwarded, so the body
+	// is only read, and the client only told to send it, once the backend asked for it.
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+	return client.Do(newReq)
 }
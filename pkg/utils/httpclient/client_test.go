@@ -2,11 +2,14 @@ package httpclient
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/stretchr/testify/assert"
@@ -121,3 +124,94 @@ func TestNewClient(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigure(t *testing.T) {
+	client := New(Options{Timeout: 10 * time.Second})
+	assert.Nil(t, client.client.Transport)
+
+	// Connection timeouts get a transport enforcing them
+	client.Configure(Options{Timeout: 5 * time.Second, ConnectTimeout: time.Second, ResponseHeaderTimeout: 2 * time.Second})
+	assert.Equal(t, 5*time.Second, client.client.Timeout)
+	transport, ok := client.client.Transport.(*http.Transport)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, transport.ResponseHeaderTimeout)
+
+	// The transport, and its connection pool, is kept while the connection timeouts are unchanged
+	client.Configure(Options{Timeout: 20 * time.Second, ConnectTimeout: time.Second, ResponseHeaderTimeout: 2 * time.Second})
+	assert.Equal(t, 20*time.Second, client.client.Timeout)
+	assert.Same(t, transport, client.client.Transport)
+}
+
+func TestForwardRequest_ResponseHeaderTimeout(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer backend.Close()
+
+	client := New(Options{ResponseHeaderTimeout: 50 * time.Millisecond})
+	_, err := client.ForwardRequest(httptest.NewRequest(http.MethodGet, "/", nil), backend.URL)
+	assert.ErrorContains(t, err, "timeout awaiting response headers")
+}
+
+func TestForwardRequest_BodyReadError(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	}))
+	defer backend.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Body = io.NopCloser(io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(errors.New("body too large"))))
+	req.ContentLength = -1
+
+	_, err := NewClient(1).ForwardRequest(req, backend.URL)
+	assert.ErrorContains(t, err, "body too large")
+}
+
+func TestForwardRequest_StreamsBody(t *testing.T) {
+	var received []byte
+	var contentLength int64
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		contentLength = r.ContentLength
+	}))
+	defer backend.Close()
+
+	tests := []struct {
+		name          string
+		body          io.Reader
+		contentLength int64
+		expected      string
+	}{
+		{name: "Known length", body: strings.NewReader("payload"), contentLength: 7, expected: "payload"},
+		{name: "Unknown length", body: io.MultiReader(strings.NewReader("pay"), strings.NewReader("load")), contentLength: -1, expected: "payload"},
+		{name: "No body", body: nil, contentLength: 0, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", tt.body)
+			req.ContentLength = tt.contentLength
+
+			resp, err := New(Options{}).ForwardRequest(req, backend.URL)
+			assert.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.expected, string(received))
+			assert.Equal(t, tt.contentLength, contentLength)
+		})
+	}
+}
+
+func TestForwardRequest_Cancelled(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer backend.Close()
+
+	// The forwarded request ends with the incoming one
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	cancel()
+
+	_, err := New(Options{}).ForwardRequest(req, backend.URL)
+	assert.ErrorIs(t, err, context.Canceled)
+}
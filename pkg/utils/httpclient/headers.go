@@ -0,0 +1,47 @@
+package httpclient
+
+import (
+	"net/http"
+	"strings"
+)
+
+// hopByHopHeaders are the headers meaningful only for a single connection (RFC 7230, section 6.1),
+// which a proxy must not forward. Proxy-Connection is not standard but still sent by some clients.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// RemoveHopByHop deletes the hop-by-hop headers from h, along with the headers the Connection header names.
+// A TE header asking for trailers is kept, as it only tells the next hop that trailers are understood.
+func RemoveHopByHop(h http.Header) {
+	for _, value := range h.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+
+	trailers := false
+	for _, value := range h.Values("Te") {
+		for _, coding := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(coding), "trailers") {
+				trailers = true
+			}
+		}
+	}
+	for _, name := range hopByHopHeaders {
+		h.Del(name)
+	}
+	if trailers {
+		h.Set("Te", "trailers")
+	}
+}
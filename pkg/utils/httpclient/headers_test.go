@@ -0,0 +1,87 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveHopByHop(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected http.Header
+	}{
+		{
+			name:     "End-to-end headers only",
+			header:   http.Header{"Accept": {"*/*"}, "Authorization": {"Bearer token"}},
+			expected: http.Header{"Accept": {"*/*"}, "Authorization": {"Bearer token"}},
+		},
+		{
+			name: "Hop-by-hop headers",
+			header: http.Header{
+				"Connection":          {"keep-alive"},
+				"Proxy-Connection":    {"keep-alive"},
+				"Keep-Alive":          {"timeout=5"},
+				"Proxy-Authenticate":  {"Basic"},
+				"Proxy-Authorization": {"Basic c2VjcmV0"},
+				"Te":                  {"gzip"},
+				"Trailer":             {"X-Checksum"},
+				"Transfer-Encoding":   {"chunked"},
+				"Upgrade":             {"websocket"},
+				"Accept":              {"*/*"},
+			},
+			expected: http.Header{"Accept": {"*/*"}},
+		},
+		{
+			name: "Headers named by Connection",
+			header: http.Header{
+				"Connection": {"X-Session, x-trace", "Close"},
+				"X-Session":  {"abc"},
+				"X-Trace":    {"1"},
+				"X-Keep":     {"yes"},
+			},
+			expected: http.Header{"X-Keep": {"yes"}},
+		},
+		{
+			name:     "TE trailers is kept",
+			header:   http.Header{"Te": {"deflate, Trailers"}},
+			expected: http.Header{"Te": {"trailers"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			RemoveHopByHop(tt.header)
+			assert.Equal(t, tt.expected, tt.header)
+		})
+	}
+}
+
+func TestForwardRequest_HopByHopHeaders(t *testing.T) {
+	var received http.Header
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer backend.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Connection", "X-Session")
+	req.Header.Set("X-Session", "abc")
+	req.Header.Set("Proxy-Authorization", "Basic c2VjcmV0")
+	req.Header.Set("X-Request-Id", "42")
+
+	resp, err := New(Options{}).ForwardRequest(req, backend.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "42", received.Get("X-Request-Id"))
+	assert.Empty(t, received.Get("X-Session"))
+	assert.Empty(t, received.Get("Proxy-Authorization"))
+
+	// The incoming request is left as received
+	assert.Equal(t, "abc", req.Header.Get("X-Session"))
+	assert.Equal(t, "X-Session", req.Header.Get("Connection"))
+}